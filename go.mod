@@ -9,6 +9,7 @@ require (
 	go.mongodb.org/mongo-driver v1.11.1
 	google.golang.org/grpc v1.51.0
 	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -1,39 +1,82 @@
 package main
 
 import (
-	"encoding/xml"
 	"flag"
+	"fmt"
 	"os"
 
 	"github.com/stockmq/stockmq-server/server"
 )
 
-func main() {
+var (
+	configFile = flag.String("c", "", "Configuration file (XML, YAML or JSON)")
+	natsURL    = flag.String("n", "nats://127.0.0.1:4222", "NATS URL")
+	monitor    = flag.String("m", "127.0.0.1:9100", "Monitor bind address")
+	grpcBind   = flag.String("g", "127.0.0.1:9101", "gRPC bind address")
+	debug      = flag.Bool("d", false, "Enable Debug messages")
+	testConfig = flag.Bool("t", false, "Test the configuration and exit")
+)
+
+// applyFlags overrides the config with flags given by visit.
+func applyFlags(cfg *server.ServerConfig, visit func(func(*flag.Flag))) {
+	visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "n":
+			cfg.NATS.URL = *natsURL
+		case "m":
+			cfg.Monitor.Bind = *monitor
+		case "g":
+			cfg.GRPC.Bind = *grpcBind
+		case "d":
+			cfg.Logger.Debug = *debug
+		}
+	})
+}
+
+// loadConfig reads the configuration file, flags set on the command line take precedence.
+func loadConfig() (server.ServerConfig, error) {
 	// Get default config.
 	cfg := server.DefaultConfig()
-	cfn := ""
+	applyFlags(&cfg, flag.VisitAll)
 
+	// Read the configuration file and override defaults.
+	if *configFile != "" {
+		if err := server.LoadConfig(*configFile, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+
+	// Apply flags again to preserve the precedence.
+	applyFlags(&cfg, flag.Visit)
+	return cfg, nil
+}
+
+func main() {
 	// Parse flags.
-	flag.StringVar(&cfn, "c", "", "Configuration file (XML)")
-	flag.StringVar(&cfg.NATS.URL, "n", "nats://127.0.0.1:4222", "NATS URL")
-	flag.StringVar(&cfg.Monitor.Bind, "m", "127.0.0.1:9100", "Monitor bind address")
-	flag.StringVar(&cfg.GRPC.Bind, "g", "127.0.0.1:9101", "gRPC bind address")
-	flag.BoolVar(&cfg.Logger.Debug, "d", false, "Enable Debug messages")
 	flag.Parse()
 
-	// Read the configuration file and override defaults.
-	if cfn != "" {
-		b, err := os.ReadFile(cfn)
-		if err != nil {
-			panic(err)
-		}
-		if err := xml.Unmarshal(b, &cfg); err != nil {
-			panic(err)
+	cfg, err := loadConfig()
+	if err != nil {
+		if *testConfig {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		panic(err)
 	}
 
-	// Parse flags again to preserve the precedence.
-	flag.Parse()
+	// Validate the configuration and exit.
+	if *testConfig {
+		errs := cfg.Validate()
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "configuration test failed: %d errors\n", len(errs))
+			os.Exit(1)
+		}
+		fmt.Println("configuration test is successful")
+		return
+	}
 
 	// Create the server.
 	s, err := server.NewServer(cfg)
@@ -41,11 +84,20 @@ func main() {
 		panic(err)
 	}
 
-	// Start the server.
+	// Configuration is read again on SIGHUP.
+	if *configFile != "" {
+		s.SetConfigLoader(loadConfig)
+	}
+
+	// Start the server, started components are closed on error.
 	if err := s.Start(); err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	// Wait until shutdown channel will be closed.
-	s.WaitForShutdown()
+	if err := s.WaitForShutdown(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -105,3 +105,717 @@ var Monitor_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/service.proto",
 }
+
+// MarketDataClient is the client API for MarketData service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MarketDataClient interface {
+	// SubscribeCandles streams candles matching the request.
+	SubscribeCandles(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MarketData_SubscribeCandlesClient, error)
+	// SubscribeQuotes streams quotes matching the request, intervals are ignored.
+	SubscribeQuotes(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MarketData_SubscribeQuotesClient, error)
+}
+
+type marketDataClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMarketDataClient(cc grpc.ClientConnInterface) MarketDataClient {
+	return &marketDataClient{cc}
+}
+
+func (c *marketDataClient) SubscribeCandles(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MarketData_SubscribeCandlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MarketData_ServiceDesc.Streams[0], "/pb.MarketData/SubscribeCandles", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &marketDataSubscribeCandlesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MarketData_SubscribeCandlesClient interface {
+	Recv() (*Candle, error)
+	grpc.ClientStream
+}
+
+type marketDataSubscribeCandlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *marketDataSubscribeCandlesClient) Recv() (*Candle, error) {
+	m := new(Candle)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *marketDataClient) SubscribeQuotes(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MarketData_SubscribeQuotesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MarketData_ServiceDesc.Streams[1], "/pb.MarketData/SubscribeQuotes", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &marketDataSubscribeQuotesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MarketData_SubscribeQuotesClient interface {
+	Recv() (*Quote, error)
+	grpc.ClientStream
+}
+
+type marketDataSubscribeQuotesClient struct {
+	grpc.ClientStream
+}
+
+func (x *marketDataSubscribeQuotesClient) Recv() (*Quote, error) {
+	m := new(Quote)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MarketDataServer is the server API for MarketData service.
+// All implementations must embed UnimplementedMarketDataServer
+// for forward compatibility
+type MarketDataServer interface {
+	// SubscribeCandles streams candles matching the request.
+	SubscribeCandles(*SubscribeRequest, MarketData_SubscribeCandlesServer) error
+	// SubscribeQuotes streams quotes matching the request, intervals are ignored.
+	SubscribeQuotes(*SubscribeRequest, MarketData_SubscribeQuotesServer) error
+	mustEmbedUnimplementedMarketDataServer()
+}
+
+// UnimplementedMarketDataServer must be embedded to have forward compatible implementations.
+type UnimplementedMarketDataServer struct {
+}
+
+func (UnimplementedMarketDataServer) SubscribeCandles(*SubscribeRequest, MarketData_SubscribeCandlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeCandles not implemented")
+}
+func (UnimplementedMarketDataServer) SubscribeQuotes(*SubscribeRequest, MarketData_SubscribeQuotesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeQuotes not implemented")
+}
+func (UnimplementedMarketDataServer) mustEmbedUnimplementedMarketDataServer() {}
+
+// UnsafeMarketDataServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MarketDataServer will
+// result in compilation errors.
+type UnsafeMarketDataServer interface {
+	mustEmbedUnimplementedMarketDataServer()
+}
+
+func RegisterMarketDataServer(s grpc.ServiceRegistrar, srv MarketDataServer) {
+	s.RegisterService(&MarketData_ServiceDesc, srv)
+}
+
+func _MarketData_SubscribeCandles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarketDataServer).SubscribeCandles(m, &marketDataSubscribeCandlesServer{stream})
+}
+
+type MarketData_SubscribeCandlesServer interface {
+	Send(*Candle) error
+	grpc.ServerStream
+}
+
+type marketDataSubscribeCandlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *marketDataSubscribeCandlesServer) Send(m *Candle) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MarketData_SubscribeQuotes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MarketDataServer).SubscribeQuotes(m, &marketDataSubscribeQuotesServer{stream})
+}
+
+type MarketData_SubscribeQuotesServer interface {
+	Send(*Quote) error
+	grpc.ServerStream
+}
+
+type marketDataSubscribeQuotesServer struct {
+	grpc.ServerStream
+}
+
+func (x *marketDataSubscribeQuotesServer) Send(m *Quote) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// MarketData_ServiceDesc is the grpc.ServiceDesc for MarketData service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MarketData_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.MarketData",
+	HandlerType: (*MarketDataServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeCandles",
+			Handler:       _MarketData_SubscribeCandles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeQuotes",
+			Handler:       _MarketData_SubscribeQuotes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pb/service.proto",
+}
+
+// HistoryClient is the client API for History service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type HistoryClient interface {
+	// GetCandles streams candles, empty source or interval match all, final selects closed bars only.
+	GetCandles(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (History_GetCandlesClient, error)
+	// GetQuotes streams quotes, interval and final are ignored.
+	GetQuotes(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (History_GetQuotesClient, error)
+}
+
+type historyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHistoryClient(cc grpc.ClientConnInterface) HistoryClient {
+	return &historyClient{cc}
+}
+
+func (c *historyClient) GetCandles(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (History_GetCandlesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &History_ServiceDesc.Streams[0], "/pb.History/GetCandles", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &historyGetCandlesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type History_GetCandlesClient interface {
+	Recv() (*Candle, error)
+	grpc.ClientStream
+}
+
+type historyGetCandlesClient struct {
+	grpc.ClientStream
+}
+
+func (x *historyGetCandlesClient) Recv() (*Candle, error) {
+	m := new(Candle)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *historyClient) GetQuotes(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (History_GetQuotesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &History_ServiceDesc.Streams[1], "/pb.History/GetQuotes", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &historyGetQuotesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type History_GetQuotesClient interface {
+	Recv() (*Quote, error)
+	grpc.ClientStream
+}
+
+type historyGetQuotesClient struct {
+	grpc.ClientStream
+}
+
+func (x *historyGetQuotesClient) Recv() (*Quote, error) {
+	m := new(Quote)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HistoryServer is the server API for History service.
+// All implementations must embed UnimplementedHistoryServer
+// for forward compatibility
+type HistoryServer interface {
+	// GetCandles streams candles, empty source or interval match all, final selects closed bars only.
+	GetCandles(*HistoryRequest, History_GetCandlesServer) error
+	// GetQuotes streams quotes, interval and final are ignored.
+	GetQuotes(*HistoryRequest, History_GetQuotesServer) error
+	mustEmbedUnimplementedHistoryServer()
+}
+
+// UnimplementedHistoryServer must be embedded to have forward compatible implementations.
+type UnimplementedHistoryServer struct {
+}
+
+func (UnimplementedHistoryServer) GetCandles(*HistoryRequest, History_GetCandlesServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetCandles not implemented")
+}
+func (UnimplementedHistoryServer) GetQuotes(*HistoryRequest, History_GetQuotesServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetQuotes not implemented")
+}
+func (UnimplementedHistoryServer) mustEmbedUnimplementedHistoryServer() {}
+
+// UnsafeHistoryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HistoryServer will
+// result in compilation errors.
+type UnsafeHistoryServer interface {
+	mustEmbedUnimplementedHistoryServer()
+}
+
+func RegisterHistoryServer(s grpc.ServiceRegistrar, srv HistoryServer) {
+	s.RegisterService(&History_ServiceDesc, srv)
+}
+
+func _History_GetCandles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HistoryServer).GetCandles(m, &historyGetCandlesServer{stream})
+}
+
+type History_GetCandlesServer interface {
+	Send(*Candle) error
+	grpc.ServerStream
+}
+
+type historyGetCandlesServer struct {
+	grpc.ServerStream
+}
+
+func (x *historyGetCandlesServer) Send(m *Candle) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _History_GetQuotes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HistoryServer).GetQuotes(m, &historyGetQuotesServer{stream})
+}
+
+type History_GetQuotesServer interface {
+	Send(*Quote) error
+	grpc.ServerStream
+}
+
+type historyGetQuotesServer struct {
+	grpc.ServerStream
+}
+
+func (x *historyGetQuotesServer) Send(m *Quote) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// History_ServiceDesc is the grpc.ServiceDesc for History service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var History_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.History",
+	HandlerType: (*HistoryServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetCandles",
+			Handler:       _History_GetCandles_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetQuotes",
+			Handler:       _History_GetQuotes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pb/service.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	// ListConnections returns all WebSocket connections.
+	ListConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListConnectionsResponse, error)
+	// ReconnectConnection closes the connection and reconnects after RetryDelay.
+	ReconnectConnection(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*Connection, error)
+	// PauseConnection closes the connection until it is resumed.
+	PauseConnection(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*Connection, error)
+	// ResumeConnection starts the paused connection.
+	ResumeConnection(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*Connection, error)
+	// ListSubscriptions returns subscriptions added at runtime.
+	ListSubscriptions(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*SubscriptionsResponse, error)
+	// Subscribe subscribes the connection to the streams, subscriptions are restored after reconnect.
+	Subscribe(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionsResponse, error)
+	// Unsubscribe unsubscribes the connection from the streams.
+	Unsubscribe(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionsResponse, error)
+	// ReconnectComponent reconnects NATS, MongoDB or WebSocket client with reset attempts, also after giving up.
+	ReconnectComponent(ctx context.Context, in *ComponentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListConnectionsResponse, error) {
+	out := new(ListConnectionsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ListConnections", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReconnectConnection(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*Connection, error) {
+	out := new(Connection)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ReconnectConnection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) PauseConnection(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*Connection, error) {
+	out := new(Connection)
+	err := c.cc.Invoke(ctx, "/pb.Admin/PauseConnection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ResumeConnection(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*Connection, error) {
+	out := new(Connection)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ResumeConnection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ListSubscriptions(ctx context.Context, in *ConnectionRequest, opts ...grpc.CallOption) (*SubscriptionsResponse, error) {
+	out := new(SubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ListSubscriptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Subscribe(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionsResponse, error) {
+	out := new(SubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/Subscribe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Unsubscribe(ctx context.Context, in *SubscriptionRequest, opts ...grpc.CallOption) (*SubscriptionsResponse, error) {
+	out := new(SubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/pb.Admin/Unsubscribe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReconnectComponent(ctx context.Context, in *ComponentRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/pb.Admin/ReconnectComponent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	// ListConnections returns all WebSocket connections.
+	ListConnections(context.Context, *emptypb.Empty) (*ListConnectionsResponse, error)
+	// ReconnectConnection closes the connection and reconnects after RetryDelay.
+	ReconnectConnection(context.Context, *ConnectionRequest) (*Connection, error)
+	// PauseConnection closes the connection until it is resumed.
+	PauseConnection(context.Context, *ConnectionRequest) (*Connection, error)
+	// ResumeConnection starts the paused connection.
+	ResumeConnection(context.Context, *ConnectionRequest) (*Connection, error)
+	// ListSubscriptions returns subscriptions added at runtime.
+	ListSubscriptions(context.Context, *ConnectionRequest) (*SubscriptionsResponse, error)
+	// Subscribe subscribes the connection to the streams, subscriptions are restored after reconnect.
+	Subscribe(context.Context, *SubscriptionRequest) (*SubscriptionsResponse, error)
+	// Unsubscribe unsubscribes the connection from the streams.
+	Unsubscribe(context.Context, *SubscriptionRequest) (*SubscriptionsResponse, error)
+	// ReconnectComponent reconnects NATS, MongoDB or WebSocket client with reset attempts, also after giving up.
+	ReconnectComponent(context.Context, *ComponentRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) ListConnections(context.Context, *emptypb.Empty) (*ListConnectionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConnections not implemented")
+}
+func (UnimplementedAdminServer) ReconnectConnection(context.Context, *ConnectionRequest) (*Connection, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconnectConnection not implemented")
+}
+func (UnimplementedAdminServer) PauseConnection(context.Context, *ConnectionRequest) (*Connection, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseConnection not implemented")
+}
+func (UnimplementedAdminServer) ResumeConnection(context.Context, *ConnectionRequest) (*Connection, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeConnection not implemented")
+}
+func (UnimplementedAdminServer) ListSubscriptions(context.Context, *ConnectionRequest) (*SubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubscriptions not implemented")
+}
+func (UnimplementedAdminServer) Subscribe(context.Context, *SubscriptionRequest) (*SubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedAdminServer) Unsubscribe(context.Context, *SubscriptionRequest) (*SubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unsubscribe not implemented")
+}
+func (UnimplementedAdminServer) ReconnectComponent(context.Context, *ComponentRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconnectComponent not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_ListConnections_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListConnections(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ListConnections",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListConnections(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReconnectConnection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReconnectConnection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ReconnectConnection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReconnectConnection(ctx, req.(*ConnectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_PauseConnection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).PauseConnection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/PauseConnection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).PauseConnection(ctx, req.(*ConnectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ResumeConnection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ResumeConnection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ResumeConnection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ResumeConnection(ctx, req.(*ConnectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ListSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ListSubscriptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListSubscriptions(ctx, req.(*ConnectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Subscribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Subscribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/Subscribe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Subscribe(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Unsubscribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Unsubscribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/Unsubscribe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Unsubscribe(ctx, req.(*SubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReconnectComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComponentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReconnectComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Admin/ReconnectComponent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReconnectComponent(ctx, req.(*ComponentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListConnections",
+			Handler:    _Admin_ListConnections_Handler,
+		},
+		{
+			MethodName: "ReconnectConnection",
+			Handler:    _Admin_ReconnectConnection_Handler,
+		},
+		{
+			MethodName: "PauseConnection",
+			Handler:    _Admin_PauseConnection_Handler,
+		},
+		{
+			MethodName: "ResumeConnection",
+			Handler:    _Admin_ResumeConnection_Handler,
+		},
+		{
+			MethodName: "ListSubscriptions",
+			Handler:    _Admin_ListSubscriptions_Handler,
+		},
+		{
+			MethodName: "Subscribe",
+			Handler:    _Admin_Subscribe_Handler,
+		},
+		{
+			MethodName: "Unsubscribe",
+			Handler:    _Admin_Unsubscribe_Handler,
+		},
+		{
+			MethodName: "ReconnectComponent",
+			Handler:    _Admin_ReconnectComponent_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pb/service.proto",
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Aggregator Configuration.
+type AggregatorConfig struct {
+	Enabled   bool     `xml:"Enabled"`
+	Intervals []string `xml:"Interval"`
+}
+
+// DefaultAggregatorConfig returns default Aggregator config.
+func DefaultAggregatorConfig() AggregatorConfig {
+	return AggregatorConfig{
+		Enabled:   false,
+		Intervals: []string{},
+	}
+}
+
+// AggregatorConfig returns Aggregator configuration.
+func (s *Server) AggregatorConfig() AggregatorConfig {
+	return s.ServerConfig().Aggregator
+}
+
+type aggregatorInterval struct {
+	name     string
+	duration time.Duration
+}
+
+type aggregatedBar struct {
+	interval string
+	start    int64
+	bar      *Candle
+	last     *Candle
+}
+
+// CandleAggregator rolls up candles into higher timeframes.
+type CandleAggregator struct {
+	mu sync.Mutex
+
+	intervals []aggregatorInterval
+	sources   map[string]time.Duration
+	bars      map[string]*aggregatedBar
+}
+
+// NewCandleAggregator returns the aggregator for the given target intervals.
+func NewCandleAggregator(intervals []string) (*CandleAggregator, error) {
+	a := &CandleAggregator{sources: make(map[string]time.Duration), bars: make(map[string]*aggregatedBar)}
+
+	for _, name := range intervals {
+		d, err := ParseInterval(name)
+		if err != nil {
+			return nil, err
+		}
+		a.intervals = append(a.intervals, aggregatorInterval{name: name, duration: d})
+	}
+	return a, nil
+}
+
+// mergeCandle merges the candle into the aggregated bar.
+func mergeCandle(bar *Candle, c *Candle) error {
+	if cmp, err := DecimalCmp(c.High, bar.High); err != nil {
+		return err
+	} else if cmp > 0 {
+		bar.High = c.High
+	}
+
+	if cmp, err := DecimalCmp(c.Low, bar.Low); err != nil {
+		return err
+	} else if cmp < 0 {
+		bar.Low = c.Low
+	}
+
+	volume, err := DecimalAdd(bar.Volume, c.Volume)
+	if err != nil {
+		return err
+	}
+
+	bar.Close = c.Close
+	bar.Volume = volume
+	bar.Final = c.Final
+	bar.TimeSrv = c.TimeSrv
+	bar.TimeRcv = c.TimeRcv
+	return nil
+}
+
+// next folds the complete source candle into the aggregated bar.
+func (b *aggregatedBar) next(c *Candle) error {
+	if b.bar != nil {
+		return mergeCandle(b.bar, c)
+	}
+
+	bar := *c
+	bar.Interval = b.interval
+	bar.Time = b.start
+	b.bar = &bar
+	return nil
+}
+
+// close returns the aggregated bar including the last source candle.
+func (b *aggregatedBar) close() (*Candle, error) {
+	if err := b.next(b.last); err != nil {
+		return nil, err
+	}
+	b.bar.Final = true
+	return b.bar, nil
+}
+
+// Add processes the candle and returns aggregated bars closed by it.
+func (a *CandleAggregator) Add(c *Candle) ([]*Candle, error) {
+	src, err := ParseInterval(c.Interval)
+	if err != nil {
+		return nil, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Bars are built from the finest source interval only
+	prefix := fmt.Sprintf("%s.%s", c.Symbol, c.Source)
+	if cur, ok := a.sources[prefix]; ok && src > cur {
+		return nil, nil
+	} else if src != cur {
+		a.sources[prefix] = src
+		for _, target := range a.intervals {
+			delete(a.bars, fmt.Sprintf("%s.%s", prefix, target.name))
+		}
+	}
+
+	r := []*Candle{}
+	for _, target := range a.intervals {
+		if target.duration <= src || target.duration%src != 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s.%s", prefix, target.name)
+		start := IntervalStart(c.Time, target.duration)
+		b := a.bars[key]
+
+		switch {
+		case b == nil || start > b.start:
+			// New window closes the previous bar
+			if b != nil {
+				bar, err := b.close()
+				if err != nil {
+					return r, err
+				}
+				r = append(r, bar)
+			}
+
+			last := *c
+			a.bars[key] = &aggregatedBar{interval: target.name, start: start, last: &last}
+		case start < b.start:
+			// Late candle of the closed window
+			continue
+		case c.Time > b.last.Time:
+			// Next source candle, the previous one is complete
+			if err := b.next(b.last); err != nil {
+				return r, err
+			}
+
+			last := *c
+			b.last = &last
+		case c.Time == b.last.Time:
+			// Update of the forming source candle
+			last := *c
+			b.last = &last
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregatorConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	srv, _ := NewServer(DefaultConfig())
+	expectDeepEqual(t, srv.AggregatorConfig(), cfg.Aggregator)
+}
+
+func TestCandleAggregator(t *testing.T) {
+	a, err := NewCandleAggregator([]string{"1m", "5m"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).UnixMicro()
+	candle := func(sec int64, open, high, low, close, volume string) *Candle {
+		return &Candle{
+			MessageHeader: MessageHeader{Symbol: "BTCUSDT", Source: "Binance", Time: start + sec*1000000},
+			Interval:      "1s",
+			Open:          open,
+			High:          high,
+			Low:           low,
+			Close:         close,
+			Volume:        volume,
+		}
+	}
+
+	for _, c := range []*Candle{
+		candle(0, "10", "11", "9", "10", "0.1"),
+		candle(0, "10", "12", "9", "11", "0.2"),
+		candle(1, "11", "11", "8", "9", "0.1"),
+		candle(59, "9", "10", "9", "10", "0.3"),
+	} {
+		if bars, err := a.Add(c); err != nil || len(bars) != 0 {
+			t.Fatalf("Unexpected bars %v: %v", bars, err)
+		}
+	}
+
+	bars, err := a.Add(candle(60, "10", "10", "10", "10", "1"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, bars, []*Candle{{
+		MessageHeader: MessageHeader{Symbol: "BTCUSDT", Source: "Binance", Time: start},
+		Interval:      "1m",
+		Open:          "10",
+		High:          "12",
+		Low:           "8",
+		Close:         "10",
+		Volume:        "0.6",
+		Final:         true,
+	}})
+}
+
+func TestCandleAggregatorSources(t *testing.T) {
+	a, err := NewCandleAggregator([]string{"5m"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	candle := func(interval string, sec int64, volume string) *Candle {
+		return &Candle{
+			MessageHeader: MessageHeader{Symbol: "BTCUSDT", Source: "Binance", Time: sec * 1000000},
+			Interval:      interval,
+			Open:          "10",
+			High:          "10",
+			Low:           "10",
+			Close:         "10",
+			Volume:        volume,
+		}
+	}
+
+	// Coarser source candles are ignored once the finer ones are received
+	bars := []*Candle{}
+	for _, c := range []*Candle{
+		candle("1s", 0, "1"),
+		candle("1m", 0, "60"),
+		candle("1s", 299, "1"),
+		candle("1m", 240, "60"),
+		candle("1m", 300, "60"),
+		candle("1s", 300, "1"),
+	} {
+		r, err := a.Add(c)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bars = append(bars, r...)
+	}
+
+	expectDeepEqual(t, len(bars), 1)
+	expectDeepEqual(t, bars[0].Interval, "5m")
+	expectDeepEqual(t, bars[0].Volume, "2")
+}
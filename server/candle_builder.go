@@ -0,0 +1,188 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Maximum number of empty bars emitted for a single gap.
+const maxEmptyBars = 1440
+
+const (
+	// Interval of checking bars closed by time.
+	candleFlushInterval = time.Second
+
+	// Delay of closing the bar by time, trades received later are ignored.
+	candleFlushDelay = 2 * time.Second
+)
+
+type builtBar struct {
+	start int64
+	bar   *Candle
+	empty bool
+}
+
+// CandleBuilder builds candles from trades.
+type CandleBuilder struct {
+	mu sync.Mutex
+
+	intervals []aggregatorInterval
+	bars      map[string]*builtBar
+}
+
+// NewCandleBuilder returns the builder for the given intervals.
+func NewCandleBuilder(intervals []string) (*CandleBuilder, error) {
+	b := &CandleBuilder{bars: make(map[string]*builtBar)}
+
+	for _, name := range intervals {
+		d, err := ParseInterval(name)
+		if err != nil {
+			return nil, err
+		}
+		b.intervals = append(b.intervals, aggregatorInterval{name: name, duration: d})
+	}
+	return b, nil
+}
+
+// newTradeBar returns the bar opened by the trade.
+func newTradeBar(t *Trade, interval string, start int64) *Candle {
+	return &Candle{
+		MessageHeader: MessageHeader{
+			Symbol:  t.Symbol,
+			Source:  t.Source,
+			Time:    start,
+			TimeSrv: t.TimeSrv,
+			TimeRcv: t.TimeRcv,
+		},
+
+		Interval: interval,
+		Open:     t.Price,
+		High:     t.Price,
+		Low:      t.Price,
+		Close:    t.Price,
+		Volume:   t.Quantity,
+	}
+}
+
+// closeBars returns the bar of the state and empty bars before the start.
+func closeBars(state *builtBar, start int64, step int64, timeSrv int64, timeRcv int64) []*Candle {
+	state.bar.Final = true
+	r := []*Candle{state.bar}
+
+	// Nothing traded between the bars, carry the previous close
+	empty := (start - state.start) / step
+	if empty > maxEmptyBars {
+		empty = maxEmptyBars
+	}
+	for i := empty - 1; i > 0; i-- {
+		bar := emptyBar(state.bar, start-i*step, timeSrv, timeRcv)
+		bar.Final = true
+		r = append(r, bar)
+	}
+	return r
+}
+
+// emptyBar returns the bar without trades at the given time which carries the close of the previous bar.
+func emptyBar(prev *Candle, start int64, timeSrv int64, timeRcv int64) *Candle {
+	bar := *prev
+	bar.Time = start
+	bar.Open = prev.Close
+	bar.High = prev.Close
+	bar.Low = prev.Close
+	bar.Volume = "0"
+	bar.TimeSrv = timeSrv
+	bar.TimeRcv = timeRcv
+	return &bar
+}
+
+// Add processes the trade and returns the bars closed by it.
+func (b *CandleBuilder) Add(t *Trade) ([]*Candle, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := []*Candle{}
+	for _, target := range b.intervals {
+		key := fmt.Sprintf("%s.%s", t.Symbol, target.name)
+		start := IntervalStart(t.Time, target.duration)
+		state := b.bars[key]
+
+		switch {
+		case state == nil:
+			b.bars[key] = &builtBar{start: start, bar: newTradeBar(t, target.name, start)}
+		case start > state.start:
+			r = append(r, closeBars(state, start, target.duration.Microseconds(), t.TimeSrv, t.TimeRcv)...)
+			b.bars[key] = &builtBar{start: start, bar: newTradeBar(t, target.name, start)}
+		case start == state.start && state.empty:
+			// The first trade of the bar opened by Flush
+			b.bars[key] = &builtBar{start: start, bar: newTradeBar(t, target.name, start)}
+		case start == state.start:
+			if err := mergeCandle(state.bar, newTradeBar(t, target.name, start)); err != nil {
+				return r, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// Flush returns bars which windows ended before now (in microseconds) without waiting for the next trade.
+func (b *CandleBuilder) Flush(now int64) []*Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := []*Candle{}
+	for _, target := range b.intervals {
+		for _, key := range sortedKeys(b.bars) {
+			state := b.bars[key]
+			if state.bar.Interval != target.name {
+				continue
+			}
+
+			start := IntervalStart(now, target.duration)
+			if start <= state.start {
+				continue
+			}
+			r = append(r, closeBars(state, start, target.duration.Microseconds(), now, now)...)
+			next := emptyBar(state.bar, start, now, now)
+			next.Final = false
+			b.bars[key] = &builtBar{start: start, bar: next, empty: true}
+		}
+	}
+	return r
+}
+
+// WSCandleFlusher periodically publishes bars of the connection closed by time.
+func (s *Server) WSCandleFlusher(conn *WSConnection, c *websocket.Conn) {
+	if conn.candleBuilder == nil {
+		return
+	}
+
+	ticker := time.NewTicker(candleFlushInterval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.quitCh:
+				return
+			}
+
+			// Stop when the websocket is closed or replaced
+			conn.RLock()
+			current := conn.wsConn == c
+			conn.RUnlock()
+			if !current {
+				return
+			}
+
+			// Late trades are accepted within the delay
+			for _, bar := range conn.candleBuilder.Flush(time.Now().Add(-candleFlushDelay).UnixMicro()) {
+				s.ProcessCandle(bar)
+			}
+		}
+	}()
+}
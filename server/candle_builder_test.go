@@ -0,0 +1,83 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCandleBuilder(t *testing.T) {
+	b, err := NewCandleBuilder([]string{"1m"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).UnixMicro()
+	trade := func(sec int64, price, quantity string) *Trade {
+		return &Trade{
+			MessageHeader: MessageHeader{Symbol: "BTC_USD", Source: "EXMO", Time: start + sec*1000000},
+			Price:         price,
+			Quantity:      quantity,
+		}
+	}
+
+	for _, tr := range []*Trade{trade(1, "10", "1"), trade(20, "12", "0.5"), trade(59, "11", "0.25")} {
+		if bars, err := b.Add(tr); err != nil || len(bars) != 0 {
+			t.Fatalf("Unexpected bars %v: %v", bars, err)
+		}
+	}
+
+	bars, err := b.Add(trade(185, "13", "1"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	header := MessageHeader{Symbol: "BTC_USD", Source: "EXMO"}
+	expected := []*Candle{
+		{MessageHeader: header, Interval: "1m", Open: "10", High: "12", Low: "10", Close: "11", Volume: "1.75"},
+		{MessageHeader: header, Interval: "1m", Open: "11", High: "11", Low: "11", Close: "11", Volume: "0"},
+		{MessageHeader: header, Interval: "1m", Open: "11", High: "11", Low: "11", Close: "11", Volume: "0"},
+	}
+	for i := range expected {
+		expected[i].Time = start + int64(i)*60000000
+		expected[i].Final = true
+	}
+	expectDeepEqual(t, bars, expected)
+}
+
+func TestCandleBuilderFlush(t *testing.T) {
+	b, err := NewCandleBuilder([]string{"1m"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).UnixMicro()
+	minute := int64(60000000)
+	b.Add(&Trade{MessageHeader: MessageHeader{Symbol: "BTC_USD", Time: start + 1000000}, Price: "10", Quantity: "1"})
+
+	expectDeepEqual(t, b.Flush(start+minute-1), []*Candle{})
+
+	// The bar and the empty bar are closed, the next bar is empty until the trade
+	bars := b.Flush(start + 2*minute + 1)
+	expectDeepEqual(t, len(bars), 2)
+	expectDeepEqual(t, bars[0].Time, start)
+	expectDeepEqual(t, bars[0].Volume, "1")
+	expectDeepEqual(t, bars[1].Time, start+minute)
+	expectDeepEqual(t, bars[1].Volume, "0")
+	expectDeepEqual(t, bars[1].Final, true)
+
+	if bars, _ := b.Add(&Trade{MessageHeader: MessageHeader{Symbol: "BTC_USD", Time: start + 2*minute + 5}, Price: "12", Quantity: "2"}); len(bars) != 0 {
+		t.Fatalf("Unexpected bars %v", bars)
+	}
+
+	bars, _ = b.Add(&Trade{MessageHeader: MessageHeader{Symbol: "BTC_USD", Time: start + 3*minute}, Price: "13", Quantity: "1"})
+	expectDeepEqual(t, bars, []*Candle{{
+		MessageHeader: MessageHeader{Symbol: "BTC_USD", Time: start + 2*minute},
+		Interval:      "1m",
+		Open:          "12",
+		High:          "12",
+		Low:           "12",
+		Close:         "12",
+		Volume:        "2",
+		Final:         true,
+	}})
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix of values read from the file.
+const secretFilePrefix = "file://"
+
+// Environment variable references in the form of ${NAME}.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references with environment variables.
+func expandEnv(s string) (string, error) {
+	var err error
+	r := envReference.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envReference.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value
+	})
+	return r, err
+}
+
+// expandValues replaces ${NAME} references in decoded string values, then file:// values with the file content.
+func expandValues(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return expandValues(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				if err := expandValues(v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := expandValues(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		s, err := expandEnv(v.String())
+		if err != nil {
+			return err
+		}
+		if path, ok := strings.CutPrefix(s, secretFilePrefix); ok {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			s = strings.TrimRight(string(b), "\r\n")
+		}
+		v.SetString(s)
+	}
+	return nil
+}
+
+// decodeJSON decodes JSON into the config, unknown fields are rejected.
+func decodeJSON(b []byte, cfg *ServerConfig) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	return d.Decode(cfg)
+}
+
+// decodeYAML decodes YAML into the config using field names of JSON.
+func decodeYAML(b []byte, cfg *ServerConfig) error {
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(b, cfg)
+}
+
+// DecodeConfig decodes the configuration in XML, YAML or JSON format over the given config.
+func DecodeConfig(b []byte, format string, cfg *ServerConfig) error {
+	var err error
+	switch format {
+	case "xml":
+		err = xml.Unmarshal(b, cfg)
+	case "yaml", "yml":
+		err = decodeYAML(b, cfg)
+	case "json":
+		err = decodeJSON(b, cfg)
+	default:
+		return fmt.Errorf("unknown configuration format '%s'", format)
+	}
+	if err != nil {
+		return err
+	}
+	return expandValues(reflect.ValueOf(cfg))
+}
+
+// LoadConfig reads the configuration file over the given config, the format is picked by extension.
+func LoadConfig(path string, cfg *ServerConfig) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format == "" {
+		format = "xml"
+	}
+	if err := DecodeConfig(b, format, cfg); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
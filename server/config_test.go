@@ -0,0 +1,64 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeConfig(t *testing.T) {
+	t.Setenv("STOCKMQ_NATS_URL", "nats://nats:4222")
+	t.Setenv("STOCKMQ_NATS_PASSWORD", `p&<"a:b'`)
+
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("STOCKMQ_TOKEN_FILE", secret)
+
+	configs := map[string]string{
+		"xml": `<Config>
+	<!-- <Token>${STOCKMQ_UNSET}</Token> -->
+	<NATS><URL>${STOCKMQ_NATS_URL}</URL><User>foo</User><Password>${STOCKMQ_NATS_PASSWORD}</Password></NATS>
+	<InfluxDB><Token>file://${STOCKMQ_TOKEN_FILE}</Token></InfluxDB>
+	<WebSocket><Name>foo</Name><InitMessage>{}</InitMessage></WebSocket>
+</Config>`,
+		"yaml": `
+# Token: ${STOCKMQ_UNSET}
+NATS:
+  URL: ${STOCKMQ_NATS_URL}
+  User: foo
+  Password: ${STOCKMQ_NATS_PASSWORD}
+InfluxDB:
+  Token: file://${STOCKMQ_TOKEN_FILE}
+WebSocket:
+  - Name: foo
+    InitMessages: ["{}"]
+`,
+		"json": `{
+	"nats": {"url": "${STOCKMQ_NATS_URL}", "user": "foo", "password": "${STOCKMQ_NATS_PASSWORD}"},
+	"influxdb": {"token": "file://${STOCKMQ_TOKEN_FILE}"},
+	"websocket": [{"name": "foo", "initMessages": ["{}"]}]
+}`,
+	}
+
+	for format, b := range configs {
+		cfg := DefaultConfig()
+		if err := DecodeConfig([]byte(b), format, &cfg); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		expectDeepEqual(t, cfg.NATS.URL, "nats://nats:4222")
+		expectDeepEqual(t, cfg.NATS.Password, `p&<"a:b'`)
+		expectDeepEqual(t, cfg.NATS.RetryDelay, DefaultNATSConfig().RetryDelay)
+		expectDeepEqual(t, cfg.InfluxDB.Token, "secret")
+		expectDeepEqual(t, cfg.WebSocket[0].Name, "foo")
+		expectDeepEqual(t, cfg.WebSocket[0].InitMessages, []string{"{}"})
+	}
+}
+
+func TestDecodeConfigErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	expectDeepEqual(t, DecodeConfig([]byte(`<Config><NATS><URL>${STOCKMQ_UNSET}</URL></NATS></Config>`), "xml", &cfg).Error(), "environment variable STOCKMQ_UNSET is not set")
+	expectDeepEqual(t, DecodeConfig([]byte(`{"Nats": {"Address": ""}}`), "json", &cfg).Error(), `json: unknown field "Address"`)
+	expectDeepEqual(t, DecodeConfig([]byte(``), "toml", &cfg).Error(), "unknown configuration format 'toml'")
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// decimalScale returns the number of fractional digits.
+func decimalScale(s string) int {
+	if _, frac, ok := strings.Cut(s, "."); ok {
+		return len(frac)
+	}
+	return 0
+}
+
+// parseDecimal parses the decimal string.
+func parseDecimal(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal '%s'", s)
+	}
+	return r, nil
+}
+
+// DecimalAdd returns the sum of decimal strings without loss of precision.
+func DecimalAdd(a string, b string) (string, error) {
+	x, err := parseDecimal(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseDecimal(b)
+	if err != nil {
+		return "", err
+	}
+	return x.Add(x, y).FloatString(max(decimalScale(a), decimalScale(b))), nil
+}
+
+// DecimalCmp compares decimal strings and returns -1, 0 or +1.
+func DecimalCmp(a string, b string) (int, error) {
+	x, err := parseDecimal(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := parseDecimal(b)
+	if err != nil {
+		return 0, err
+	}
+	return x.Cmp(y), nil
+}
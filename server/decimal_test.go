@@ -0,0 +1,11 @@
+package server
+
+import "testing"
+
+func TestDecimal(t *testing.T) {
+	expectDeepEqual(t, Unwrap(DecimalAdd("0.1", "0.20")), "0.30")
+	expectDeepEqual(t, Unwrap(DecimalCmp("10.5", "9.99")), 1)
+	if _, err := DecimalAdd("foo", "1"); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
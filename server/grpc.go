@@ -4,7 +4,6 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
-	"os"
 
 	"github.com/stockmq/stockmq-server/pb"
 	"google.golang.org/grpc"
@@ -17,6 +16,8 @@ type GRPCConfig struct {
 	TLS            bool   `xml:"TLS"`
 	TLSCertificate string `xml:"TLSCertificate"`
 	TLSKey         string `xml:"TLSKey"`
+	StreamBuffer   int    `xml:"StreamBuffer"`
+	HistoryLimit   int    `xml:"HistoryLimit"`
 }
 
 // DefaultGRPCConfig returns default GRPC config
@@ -26,6 +27,8 @@ func DefaultGRPCConfig() GRPCConfig {
 		TLS:            false,
 		TLSCertificate: "",
 		TLSKey:         "",
+		StreamBuffer:   1000,
+		HistoryLimit:   100000,
 	}
 }
 
@@ -39,11 +42,6 @@ func (s *Server) StartGRPC() error {
 	cfg := s.GRPCConfig()
 	s.Noticef("Starting GRPC on %v tls: %v", cfg.Bind, cfg.TLS)
 
-	grpcListener, err := net.Listen("tcp", cfg.Bind)
-	if err != nil {
-		return fmt.Errorf("GRPC: cannot listen on %s: %v", cfg.Bind, err)
-	}
-
 	opts := []grpc.ServerOption{}
 
 	if cfg.TLS {
@@ -60,8 +58,16 @@ func (s *Server) StartGRPC() error {
 		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 
+	grpcListener, err := net.Listen("tcp", cfg.Bind)
+	if err != nil {
+		return fmt.Errorf("GRPC: cannot listen on %s: %v", cfg.Bind, err)
+	}
+
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterMonitorServer(grpcServer, &Backend{s: s})
+	pb.RegisterMarketDataServer(grpcServer, &MarketDataBackend{s: s})
+	pb.RegisterHistoryServer(grpcServer, &HistoryBackend{s: s})
+	pb.RegisterAdminServer(grpcServer, &AdminBackend{s: s})
 
 	s.mu.Lock()
 	s.grpcListener = grpcListener
@@ -70,12 +76,7 @@ func (s *Server) StartGRPC() error {
 
 	go func() {
 		if err := grpcServer.Serve(grpcListener); err != nil {
-			if !s.IsShutdown() {
-				s.Errorf("GRPC: error serving: %v", err)
-
-				// TODO (nusov): cancel Start() and close all open connections before exit
-				os.Exit(1)
-			}
+			s.Fatal(fmt.Errorf("GRPC: error serving: %v", err))
 		}
 	}()
 
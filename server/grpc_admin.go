@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stockmq/stockmq-server/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// AdminBackend is used to implement pb.Admin.
+type AdminBackend struct {
+	pb.UnimplementedAdminServer
+	s *Server
+}
+
+// PBConnection converts the connection state to protobuf message.
+func PBConnection(conn *WSConnection) *pb.Connection {
+	cfg := conn.Config()
+	return &pb.Connection{
+		Name:         cfg.Name,
+		Url:          cfg.URL,
+		Handler:      cfg.Handler,
+		State:        conn.State(),
+		Reconnecting: conn.IsWSReconnecting(),
+		LastMessage:  conn.wsLastMessage.Load(),
+	}
+}
+
+// connection returns the connection by name or NotFound status.
+func (b *AdminBackend) connection(in *pb.ConnectionRequest) (*WSConnection, error) {
+	conn := b.s.WSConnection(in.Name)
+	if conn == nil {
+		return nil, status.Errorf(codes.NotFound, "connection '%s' not found", in.Name)
+	}
+	return conn, nil
+}
+
+// ListConnections returns all WebSocket connections.
+func (b *AdminBackend) ListConnections(ctx context.Context, in *emptypb.Empty) (*pb.ListConnectionsResponse, error) {
+	r := &pb.ListConnectionsResponse{}
+	for _, conn := range b.s.WSConnections() {
+		r.Connections = append(r.Connections, PBConnection(conn))
+	}
+	return r, nil
+}
+
+// ReconnectConnection closes the connection and schedules reconnect.
+func (b *AdminBackend) ReconnectConnection(ctx context.Context, in *pb.ConnectionRequest) (*pb.Connection, error) {
+	conn, err := b.connection(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.s.WSReconnect(conn); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return PBConnection(conn), nil
+}
+
+// PauseConnection closes the connection until it is resumed.
+func (b *AdminBackend) PauseConnection(ctx context.Context, in *pb.ConnectionRequest) (*pb.Connection, error) {
+	conn, err := b.connection(in)
+	if err != nil {
+		return nil, err
+	}
+	b.s.WSPause(conn)
+	return PBConnection(conn), nil
+}
+
+// ResumeConnection starts the paused connection.
+func (b *AdminBackend) ResumeConnection(ctx context.Context, in *pb.ConnectionRequest) (*pb.Connection, error) {
+	conn, err := b.connection(in)
+	if err != nil {
+		return nil, err
+	}
+	b.s.WSResume(conn)
+	return PBConnection(conn), nil
+}
+
+// pbSubscriptions returns active subscriptions of the connection.
+func pbSubscriptions(conn *WSConnection) *pb.SubscriptionsResponse {
+	return &pb.SubscriptionsResponse{Name: conn.Config().Name, Streams: conn.Subscriptions()}
+}
+
+// ListSubscriptions returns subscriptions added at runtime.
+func (b *AdminBackend) ListSubscriptions(ctx context.Context, in *pb.ConnectionRequest) (*pb.SubscriptionsResponse, error) {
+	conn, err := b.connection(in)
+	if err != nil {
+		return nil, err
+	}
+	return pbSubscriptions(conn), nil
+}
+
+// Subscribe subscribes the connection to the streams.
+func (b *AdminBackend) Subscribe(ctx context.Context, in *pb.SubscriptionRequest) (*pb.SubscriptionsResponse, error) {
+	conn, err := b.connection(&pb.ConnectionRequest{Name: in.Name})
+	if err != nil {
+		return nil, err
+	}
+	if err := b.s.WSSubscribe(conn, in.Streams); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return pbSubscriptions(conn), nil
+}
+
+// Unsubscribe unsubscribes the connection from the streams.
+func (b *AdminBackend) Unsubscribe(ctx context.Context, in *pb.SubscriptionRequest) (*pb.SubscriptionsResponse, error) {
+	conn, err := b.connection(&pb.ConnectionRequest{Name: in.Name})
+	if err != nil {
+		return nil, err
+	}
+	if err := b.s.WSUnsubscribe(conn, in.Streams); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return pbSubscriptions(conn), nil
+}
+
+// ReconnectComponent reconnects the client, the component which gave up is started again.
+func (b *AdminBackend) ReconnectComponent(ctx context.Context, in *pb.ComponentRequest) (*emptypb.Empty, error) {
+	if err := b.s.ReconnectComponent(in.Name); err != nil {
+		if errors.Is(err, ErrUnknownComponent) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
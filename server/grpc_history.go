@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stockmq/stockmq-server/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Trailer key of the token of the next page.
+const historyNextTrailer = "history-next"
+
+// historyToken returns the resume token of the message time and ID.
+func historyToken(t int64, id primitive.ObjectID) string {
+	return fmt.Sprintf("%d:%s", t, id.Hex())
+}
+
+// parseHistoryToken returns the message time and ID of the resume token.
+func parseHistoryToken(token string) (int64, primitive.ObjectID, error) {
+	ts, hex, ok := strings.Cut(token, ":")
+	if !ok {
+		return 0, primitive.NilObjectID, fmt.Errorf("invalid token '%s'", token)
+	}
+
+	t, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, primitive.NilObjectID, fmt.Errorf("invalid token '%s'", token)
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return 0, primitive.NilObjectID, fmt.Errorf("invalid token '%s'", token)
+	}
+	return t, id, nil
+}
+
+// HistoryBackend is used to implement pb.History.
+type HistoryBackend struct {
+	pb.UnimplementedHistoryServer
+	s *Server
+}
+
+// historyFilter returns the MongoDB filter for the request, messages after the token are selected if it is set.
+func historyFilter(in *pb.HistoryRequest, candles bool) (bson.D, error) {
+	filter := bson.D{{Key: "messageheader.symbol", Value: in.Symbol}}
+	if in.Source != "" {
+		filter = append(filter, bson.E{Key: "messageheader.source", Value: in.Source})
+	}
+	if candles && in.Interval != "" {
+		filter = append(filter, bson.E{Key: "interval", Value: in.Interval})
+	}
+	if candles && in.Final {
+		filter = append(filter, bson.E{Key: "final", Value: true})
+	}
+
+	t := bson.D{{Key: "$gte", Value: in.From}}
+	if in.To > 0 {
+		t = append(t, bson.E{Key: "$lt", Value: in.To})
+	}
+	filter = append(filter, bson.E{Key: "messageheader.time", Value: t})
+
+	if in.After != "" {
+		after, id, err := parseHistoryToken(in.After)
+		if err != nil {
+			return nil, err
+		}
+
+		// Messages with the same time are ordered by ID
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "messageheader.time", Value: bson.D{{Key: "$gt", Value: after}}}},
+			bson.D{{Key: "messageheader.time", Value: after}, {Key: "_id", Value: bson.D{{Key: "$gt", Value: id}}}},
+		}})
+	}
+	return filter, nil
+}
+
+// historyCollection returns the collection or Unavailable status if MongoDB is not connected.
+func (s *Server) historyCollection(name string) (*mongo.Collection, error) {
+	cfg := s.MongoDBConfig()
+	if !cfg.Enabled {
+		return nil, status.Error(codes.Unavailable, "MongoDB is disabled")
+	}
+
+	s.mongoMu.RLock()
+	client := s.mongoClient
+	s.mongoMu.RUnlock()
+
+	if client == nil || s.IsMongoDBReconnecting() {
+		return nil, status.Error(codes.Unavailable, "MongoDB is not connected")
+	}
+	return client.Database(cfg.Database).Collection(name), nil
+}
+
+// historyError converts MongoDB errors to gRPC status.
+func historyError(err error) error {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return status.Errorf(codes.Unavailable, "MongoDB: %v", err)
+	}
+	return status.Errorf(codes.Internal, "MongoDB: %v", err)
+}
+
+// streamHistory queries the collection and sends decoded messages, returns the token of the next page if the page is full.
+func streamHistory[T any](s *Server, ctx context.Context, collection string, in *pb.HistoryRequest, candles bool, send func(*T) error) (string, error) {
+	if in.Symbol == "" {
+		return "", status.Error(codes.InvalidArgument, "symbol is required")
+	}
+	if in.To > 0 && in.To <= in.From {
+		return "", status.Error(codes.InvalidArgument, "invalid time range")
+	}
+
+	filter, err := historyFilter(in, candles)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	c, err := s.historyCollection(collection)
+	if err != nil {
+		return "", err
+	}
+
+	limit := s.GRPCConfig().HistoryLimit
+	if in.Limit > 0 && (limit < 1 || int(in.Limit) < limit) {
+		limit = int(in.Limit)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "messageheader.time", Value: 1}, {Key: "_id", Value: 1}}).
+		SetBatchSize(int32(s.MongoDBConfig().BatchSize))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cursor, err := c.Find(ctx, filter, opts)
+	if err != nil {
+		return "", historyError(err)
+	}
+	defer cursor.Close(context.Background())
+
+	n := 0
+	last := ""
+	for cursor.Next(ctx) {
+		var m T
+		if err := cursor.Decode(&m); err != nil {
+			return "", historyError(err)
+		}
+		if err := send(&m); err != nil {
+			return "", err
+		}
+
+		n++
+		t, _ := cursor.Current.Lookup("messageheader", "time").AsInt64OK()
+		if id, ok := cursor.Current.Lookup("_id").ObjectIDOK(); ok {
+			last = historyToken(t, id)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", historyError(err)
+	}
+
+	// Less messages than the limit means the last page
+	if limit < 1 || n < limit {
+		return "", nil
+	}
+	return last, nil
+}
+
+// setHistoryNext sets the trailer with the token of the next page.
+func setHistoryNext(stream grpc.ServerStream, next string, err error) error {
+	if err == nil && next != "" {
+		stream.SetTrailer(metadata.Pairs(historyNextTrailer, next))
+	}
+	return err
+}
+
+// GetCandles streams stored candles matching the request.
+func (b *HistoryBackend) GetCandles(in *pb.HistoryRequest, stream pb.History_GetCandlesServer) error {
+	next, err := streamHistory(b.s, stream.Context(), b.s.MongoDBConfig().Candles, in, true, func(c *Candle) error {
+		return stream.Send(PBCandle(c))
+	})
+	return setHistoryNext(stream, next, err)
+}
+
+// GetQuotes streams stored quotes matching the request.
+func (b *HistoryBackend) GetQuotes(in *pb.HistoryRequest, stream pb.History_GetQuotesServer) error {
+	next, err := streamHistory(b.s, stream.Context(), b.s.MongoDBConfig().Quotes, in, false, func(q *Quote) error {
+		return stream.Send(PBQuote(q))
+	})
+	return setHistoryNext(stream, next, err)
+}
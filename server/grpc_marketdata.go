@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"path"
+
+	"github.com/stockmq/stockmq-server/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MarketDataBackend is used to implement pb.MarketData.
+type MarketDataBackend struct {
+	pb.UnimplementedMarketDataServer
+	s *Server
+}
+
+// marketDataStream is the subscription of the gRPC client.
+type marketDataStream struct {
+	filter  *pb.SubscribeRequest
+	candles bool
+	ch      chan Message
+	slow    chan struct{}
+}
+
+// matchAny returns whether the value matches any of the patterns, empty list matches all.
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSubscribeRequest checks the patterns of the request.
+func validateSubscribeRequest(in *pb.SubscribeRequest) error {
+	for _, patterns := range [][]string{in.Symbols, in.Sources, in.Intervals} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid pattern '%s'", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// matches returns whether the message is requested by the stream.
+func (st *marketDataStream) matches(m Message) bool {
+	h := m.Header()
+	if !matchAny(st.filter.Symbols, h.Symbol) || !matchAny(st.filter.Sources, h.Source) {
+		return false
+	}
+
+	switch m := m.(type) {
+	case *Candle:
+		return st.candles && matchAny(st.filter.Intervals, m.Interval)
+	case *Quote:
+		return !st.candles
+	}
+	return false
+}
+
+// publishMarketData sends the message to the matching gRPC streams without blocking.
+func (s *Server) publishMarketData(m Message) {
+	s.streamMu.RLock()
+	defer s.streamMu.RUnlock()
+
+	for st := range s.streams {
+		if !st.matches(m) {
+			continue
+		}
+
+		select {
+		case st.ch <- m:
+		default:
+			// The client does not keep up, the stream is closed by the handler
+			select {
+			case st.slow <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// streamMarketData sends the matching messages until the client disconnects.
+func (s *Server) streamMarketData(ctx context.Context, in *pb.SubscribeRequest, candles bool, send func(Message) error) error {
+	if err := validateSubscribeRequest(in); err != nil {
+		return err
+	}
+
+	st := &marketDataStream{
+		filter:  in,
+		candles: candles,
+		ch:      make(chan Message, s.GRPCConfig().StreamBuffer),
+		slow:    make(chan struct{}, 1),
+	}
+
+	s.streamMu.Lock()
+	s.streams[st] = struct{}{}
+	s.streamMu.Unlock()
+
+	defer func() {
+		s.streamMu.Lock()
+		delete(s.streams, st)
+		s.streamMu.Unlock()
+	}()
+
+	for {
+		select {
+		case m := <-st.ch:
+			if err := send(m); err != nil {
+				return err
+			}
+		case <-st.slow:
+			s.Warnf("GRPC: closing slow market data stream")
+			return status.Error(codes.ResourceExhausted, "slow consumer")
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.quitCh:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
+
+// pbMessageHeader converts the header to protobuf message.
+func pbMessageHeader(h *MessageHeader) *pb.MessageHeader {
+	return &pb.MessageHeader{
+		Symbol:  h.Symbol,
+		Source:  h.Source,
+		Time:    h.Time,
+		TimeSrv: h.TimeSrv,
+		TimeRcv: h.TimeRcv,
+	}
+}
+
+// pbPriceLevels converts price levels to protobuf messages.
+func pbPriceLevels(levels [][]string) []*pb.PriceLevel {
+	r := make([]*pb.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		l := &pb.PriceLevel{}
+		if len(level) > 0 {
+			l.Price = level[0]
+		}
+		if len(level) > 1 {
+			l.Quantity = level[1]
+		}
+		r = append(r, l)
+	}
+	return r
+}
+
+// PBCandle converts the candle to protobuf message.
+func PBCandle(c *Candle) *pb.Candle {
+	return &pb.Candle{
+		Header:   pbMessageHeader(&c.MessageHeader),
+		Interval: c.Interval,
+		Open:     c.Open,
+		High:     c.High,
+		Low:      c.Low,
+		Close:    c.Close,
+		Volume:   c.Volume,
+		Final:    c.Final,
+	}
+}
+
+// PBQuote converts the quote to protobuf message.
+func PBQuote(q *Quote) *pb.Quote {
+	return &pb.Quote{
+		Header:    pbMessageHeader(&q.MessageHeader),
+		BidsDepth: int32(q.BidsDepth),
+		Bids:      pbPriceLevels(q.Bids),
+		AsksDepth: int32(q.AsksDepth),
+		Asks:      pbPriceLevels(q.Asks),
+	}
+}
+
+// SubscribeCandles streams candles matching the request.
+func (b *MarketDataBackend) SubscribeCandles(in *pb.SubscribeRequest, stream pb.MarketData_SubscribeCandlesServer) error {
+	return b.s.streamMarketData(stream.Context(), in, true, func(m Message) error {
+		return stream.Send(PBCandle(m.(*Candle)))
+	})
+}
+
+// SubscribeQuotes streams quotes matching the request.
+func (b *MarketDataBackend) SubscribeQuotes(in *pb.SubscribeRequest, stream pb.MarketData_SubscribeQuotesServer) error {
+	return b.s.streamMarketData(stream.Context(), in, false, func(m Message) error {
+		return stream.Send(PBQuote(m.(*Quote)))
+	})
+}
@@ -16,5 +16,5 @@ type Backend struct {
 
 // IsRunning returns whether service is in running state.
 func (b *Backend) IsRunning(ctx context.Context, in *emptypb.Empty) (*wrapperspb.BoolValue, error) {
-	return wrapperspb.Bool(b.s.healthStatus().Error == ""), nil
+	return wrapperspb.Bool(b.s.readinessStatus().Error == ""), nil
 }
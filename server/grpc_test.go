@@ -1,9 +1,142 @@
 package server
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stockmq/stockmq-server/pb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
 
 func TestGRPCConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	srv, _ := NewServer(DefaultConfig())
 	expectDeepEqual(t, srv.GRPCConfig(), cfg.GRPC)
 }
+
+func TestMarketDataStreamMatches(t *testing.T) {
+	st := &marketDataStream{
+		filter:  &pb.SubscribeRequest{Symbols: []string{"BTC*"}, Intervals: []string{"1m"}},
+		candles: true,
+	}
+
+	expectDeepEqual(t, st.matches(&Candle{MessageHeader: MessageHeader{Symbol: "BTCUSDT"}, Interval: "1m"}), true)
+	expectDeepEqual(t, st.matches(&Candle{MessageHeader: MessageHeader{Symbol: "BTCUSDT"}, Interval: "5m"}), false)
+	expectDeepEqual(t, st.matches(&Candle{MessageHeader: MessageHeader{Symbol: "ETHUSDT"}, Interval: "1m"}), false)
+	expectDeepEqual(t, st.matches(&Quote{MessageHeader: MessageHeader{Symbol: "BTCUSDT"}}), false)
+
+	if err := validateSubscribeRequest(&pb.SubscribeRequest{Symbols: []string{"[BTC"}}); err == nil {
+		t.Fatalf("Expected error for invalid pattern")
+	}
+}
+
+func TestMarketDataSlowConsumer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GRPC.StreamBuffer = 1
+	srv, _ := NewServer(cfg)
+
+	errCh := make(chan error)
+	block := make(chan struct{})
+	go func() {
+		errCh <- srv.streamMarketData(context.Background(), &pb.SubscribeRequest{}, false, func(m Message) error {
+			<-block
+			return nil
+		})
+	}()
+
+	// Wait for the subscription
+	for {
+		srv.streamMu.RLock()
+		n := len(srv.streams)
+		srv.streamMu.RUnlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The handler waits in send, the buffer is full after two messages
+	for i := 0; i < 3; i++ {
+		srv.publishMarketData(&Quote{MessageHeader: MessageHeader{Symbol: "foo"}})
+	}
+	close(block)
+
+	expectDeepEqual(t, status.Code(<-errCh), codes.ResourceExhausted)
+}
+
+func TestPBQuote(t *testing.T) {
+	q := PBQuote(&Quote{MessageHeader: MessageHeader{Symbol: "foo", Source: "bar"}, BidsDepth: 1, Bids: [][]string{{"1.5"}}})
+	expectDeepEqual(t, q.Header.Symbol, "foo")
+	expectDeepEqual(t, q.Bids[0].Price, "1.5")
+	expectDeepEqual(t, q.Bids[0].Quantity, "")
+}
+
+func TestHistoryFilter(t *testing.T) {
+	in := &pb.HistoryRequest{Symbol: "BTCUSDT", Interval: "1m", From: 100, To: 200, Final: true}
+
+	expectDeepEqual(t, Unwrap(historyFilter(in, true)), bson.D{
+		{Key: "messageheader.symbol", Value: "BTCUSDT"},
+		{Key: "interval", Value: "1m"},
+		{Key: "final", Value: true},
+		{Key: "messageheader.time", Value: bson.D{{Key: "$gte", Value: int64(100)}, {Key: "$lt", Value: int64(200)}}},
+	})
+	expectDeepEqual(t, Unwrap(historyFilter(in, false)), bson.D{
+		{Key: "messageheader.symbol", Value: "BTCUSDT"},
+		{Key: "messageheader.time", Value: bson.D{{Key: "$gte", Value: int64(100)}, {Key: "$lt", Value: int64(200)}}},
+	})
+}
+
+func TestHistoryToken(t *testing.T) {
+	id := primitive.NewObjectID()
+	token := historyToken(150, id)
+	in := &pb.HistoryRequest{Symbol: "BTCUSDT", From: 100, After: token}
+
+	// Messages with the time of the last message follow its ID
+	expectDeepEqual(t, Unwrap(historyFilter(in, false)), bson.D{
+		{Key: "messageheader.symbol", Value: "BTCUSDT"},
+		{Key: "messageheader.time", Value: bson.D{{Key: "$gte", Value: int64(100)}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "messageheader.time", Value: bson.D{{Key: "$gt", Value: int64(150)}}}},
+			bson.D{{Key: "messageheader.time", Value: int64(150)}, {Key: "_id", Value: bson.D{{Key: "$gt", Value: id}}}},
+		}},
+	})
+
+	in.After = "150"
+	if _, err := historyFilter(in, false); err == nil {
+		t.Fatalf("Expected error for invalid token")
+	}
+}
+
+func TestHistoryUnavailable(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+
+	_, err := streamHistory(srv, context.Background(), "candles", &pb.HistoryRequest{Symbol: "BTCUSDT"}, true, func(c *Candle) error {
+		return nil
+	})
+	expectDeepEqual(t, status.Code(err), codes.Unavailable)
+}
+
+func TestAdminConnections(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WebSocket = []WSConfig{{Name: "foo", Enabled: true, URL: "wss://localhost", Handler: "Debug"}}
+	srv, _ := NewServer(cfg)
+	admin := &AdminBackend{s: srv}
+
+	r, _ := admin.ListConnections(context.Background(), &emptypb.Empty{})
+	expectDeepEqual(t, len(r.Connections), 1)
+	expectDeepEqual(t, r.Connections[0].State, WSStateDisconnected)
+
+	c, _ := admin.PauseConnection(context.Background(), &pb.ConnectionRequest{Name: "foo"})
+	expectDeepEqual(t, c.State, WSStatePaused)
+
+	_, err := admin.ReconnectConnection(context.Background(), &pb.ConnectionRequest{Name: "foo"})
+	expectDeepEqual(t, status.Code(err), codes.FailedPrecondition)
+
+	_, err = admin.PauseConnection(context.Background(), &pb.ConnectionRequest{Name: "bar"})
+	expectDeepEqual(t, status.Code(err), codes.NotFound)
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Component states.
+const (
+	ComponentUp   = "up"
+	ComponentDown = "down"
+)
+
+// Component names, WebSocket connections are named ws/<Name>.
+const (
+	ComponentServer   = "server"
+	ComponentProcess  = "process"
+	ComponentNATS     = "nats"
+	ComponentMongoDB  = "mongodb"
+	ComponentInfluxDB = "influxdb"
+)
+
+// wsComponent returns the component name of the connection.
+func wsComponent(name string) string {
+	return "ws/" + name
+}
+
+// ComponentStatus represents the state of the component.
+type ComponentStatus struct {
+	State    string    `json:"state"`
+	Error    string    `json:"error,omitempty"`
+	Since    time.Time `json:"since"`
+	Required bool      `json:"required,omitempty"`
+
+	// Reconnect loop of the client
+	Reconnect *ReconnectStatus `json:"reconnect,omitempty"`
+}
+
+// healthRegistry tracks state changes and last errors of the components.
+type healthRegistry struct {
+	mu         sync.Mutex
+	components map[string]*ComponentStatus
+}
+
+// set updates the state of the component, the error is kept as the last error.
+func (h *healthRegistry) set(name string, up bool, err error) ComponentStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.components == nil {
+		h.components = make(map[string]*ComponentStatus)
+	}
+
+	state := ComponentDown
+	if up {
+		state = ComponentUp
+	}
+
+	c := h.components[name]
+	if c == nil {
+		c = &ComponentStatus{State: state, Since: time.Now()}
+		h.components[name] = c
+	}
+	if c.State != state {
+		c.State = state
+		c.Since = time.Now()
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	return *c
+}
+
+// recordError keeps the last error without changing the state.
+func (h *healthRegistry) recordError(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c := h.components[name]; c != nil {
+		c.Error = err.Error()
+	}
+}
+
+// remove deletes the component.
+func (h *healthRegistry) remove(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.components, name)
+}
+
+// componentUp marks the component as connected.
+func (s *Server) componentUp(name string) {
+	s.health.set(name, true, nil)
+}
+
+// componentDown marks the component as failed.
+func (s *Server) componentDown(name string, err error) {
+	s.health.set(name, false, err)
+}
+
+// RequiredComponents returns components required for readiness.
+func (s *Server) RequiredComponents() []string {
+	if required := s.MonitorConfig().Required; len(required) > 0 {
+		return required
+	}
+
+	r := []string{ComponentNATS}
+	if s.MongoDBConfig().Enabled {
+		r = append(r, ComponentMongoDB)
+	}
+	if s.InfluxDBConfig().Enabled {
+		r = append(r, ComponentInfluxDB)
+	}
+	return r
+}
+
+// withReconnect adds the reconnect state to the component status.
+func withReconnect(c ComponentStatus, r *Reconnector) ComponentStatus {
+	status := r.Status()
+	c.Reconnect = &status
+	return c
+}
+
+// probeComponents checks the connections and returns state of all components.
+func (s *Server) probeComponents() map[string]ComponentStatus {
+	r := map[string]ComponentStatus{}
+
+	// Check NATS
+	s.ncMu.RLock()
+	nc := s.ncConn
+	s.ncMu.RUnlock()
+	r[ComponentNATS] = withReconnect(s.health.set(ComponentNATS, nc != nil && nc.IsConnected(), nil), s.natsReconnect)
+
+	// Check MongoDB
+	if s.MongoDBConfig().Enabled {
+		s.mongoMu.RLock()
+		client := s.mongoClient
+		s.mongoMu.RUnlock()
+
+		var err error
+		if client != nil && !s.IsMongoDBReconnecting() {
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			err = client.Ping(ctx, readpref.Primary())
+			cancel()
+		}
+		r[ComponentMongoDB] = withReconnect(s.health.set(ComponentMongoDB, client != nil && !s.IsMongoDBReconnecting() && err == nil, err), s.mongoReconnect)
+	}
+
+	// Check InfluxDB
+	if s.InfluxDBConfig().Enabled {
+		s.mu.RLock()
+		client := s.dbClient
+		s.mu.RUnlock()
+
+		up := false
+		if client != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			pong, err := client.Ping(ctx)
+			cancel()
+			up = err == nil && pong
+		}
+		r[ComponentInfluxDB] = s.health.set(ComponentInfluxDB, up, nil)
+	}
+
+	// Check WebSockets
+	for _, conn := range s.WSConnections() {
+		name := wsComponent(conn.Config().Name)
+		r[name] = withReconnect(s.health.set(name, conn.State() == WSStateConnected, nil), conn.reconnect)
+	}
+	return r
+}
+
+// readinessStatus returns whether the startup is complete and required components are connected.
+func (s *Server) readinessStatus() *HealthStatus {
+	components := s.probeComponents()
+
+	started := false
+	select {
+	case <-s.startupComplete:
+		started = !s.IsShutdown()
+	default:
+	}
+	components[ComponentServer] = s.health.set(ComponentServer, started, nil)
+
+	failures := []string{}
+	if !started {
+		failures = append(failures, ComponentServer)
+	}
+
+	for _, name := range s.RequiredComponents() {
+		c, ok := components[name]
+		if !ok {
+			c = ComponentStatus{State: ComponentDown, Error: "unknown component"}
+		}
+		c.Required = true
+		components[name] = c
+
+		if c.State != ComponentUp {
+			failures = append(failures, name)
+		}
+	}
+	return newHealthStatus(components, failures, "not ready")
+}
+
+// livenessStatus returns whether the server and its workers are healthy.
+func (s *Server) livenessStatus() *HealthStatus {
+	components := map[string]ComponentStatus{}
+	failures := []string{}
+
+	timeout := time.Duration(s.MonitorConfig().LivenessTimeout) * time.Second
+
+	components[ComponentProcess] = s.health.set(ComponentProcess, !s.IsShutdown(), nil)
+	if s.IsShutdown() {
+		failures = append(failures, ComponentProcess)
+	}
+
+	// Sink worker does not take messages from the queue
+	s.sinkMu.Lock()
+	for name, q := range s.sinkQueues {
+		idle := time.Since(time.UnixMicro(q.active.Load()))
+
+		var err error
+		if timeout > 0 && len(q.ch) > 0 && idle > timeout {
+			err = fmt.Errorf("no progress for %v", idle.Truncate(time.Second))
+			failures = append(failures, "sink/"+name)
+		}
+		components["sink/"+name] = s.health.set("sink/"+name, err == nil, err)
+	}
+	s.sinkMu.Unlock()
+
+	return newHealthStatus(components, failures, "unhealthy")
+}
+
+// newHealthStatus returns the status with components, failures are reported as error.
+func newHealthStatus(components map[string]ComponentStatus, failures []string, reason string) *HealthStatus {
+	if len(failures) == 0 {
+		return &HealthStatus{Status: "ok", Components: components}
+	}
+
+	sort.Strings(failures)
+	return &HealthStatus{Status: "error", Error: fmt.Sprintf("%s: %v", reason, failures), Components: components}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHealthRegistry(t *testing.T) {
+	h := healthRegistry{}
+
+	down := h.set("foo", false, fmt.Errorf("failed"))
+	expectDeepEqual(t, down.State, ComponentDown)
+
+	// Last error is kept, since is updated on state change
+	up := h.set("foo", true, nil)
+	expectDeepEqual(t, up.State, ComponentUp)
+	expectDeepEqual(t, up.Error, "failed")
+	expectDeepEqual(t, up.Since.Before(down.Since), false)
+	expectDeepEqual(t, h.set("foo", true, nil).Since, up.Since)
+}
+
+func TestReadiness(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Monitor.Required = []string{"ws/foo", "bar"}
+	cfg.WebSocket = []WSConfig{{Name: "foo", Enabled: true, Handler: "Debug"}}
+	srv, _ := NewServer(cfg)
+
+	status := srv.readinessStatus()
+	expectDeepEqual(t, status.Error, "not ready: [bar server ws/foo]")
+	expectDeepEqual(t, status.Components["ws/foo"].Required, true)
+	expectDeepEqual(t, status.Components["nats"].Required, false)
+
+	// Dependencies do not affect liveness
+	expectDeepEqual(t, srv.livenessStatus().Error, "")
+}
+
+func TestRequiredComponents(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MongoDB.Enabled = true
+	srv, _ := NewServer(cfg)
+	expectDeepEqual(t, srv.RequiredComponents(), []string{"nats", "mongodb"})
+}
@@ -57,6 +57,8 @@ func (s *Server) StartInfluxDB() {
 			select {
 			case err := <-errorsCh:
 				if err != nil {
+					s.metrics.InfluxDBErrors.Inc()
+					s.health.recordError(ComponentInfluxDB, err)
 					s.Errorf("InfluxDB write error: %v", err)
 				}
 			case <-s.quitCh:
@@ -106,9 +108,35 @@ func (m *Quote) InfluxDBPoint() *write.Point {
 	return p
 }
 
+// InfluxDBPoint returns the Point.
+func (m *Trade) InfluxDBPoint() *write.Point {
+	p := influxdb2.NewPoint(
+		"trade",
+		map[string]string{"symbol": m.Symbol, "source": m.Source, "side": m.Side},
+		map[string]interface{}{
+			"time_srv":    m.TimeSrv,
+			"time_rcv":    m.TimeRcv,
+			"trade_id":    m.TradeID,
+			"price":       Unwrap(strconv.ParseFloat(m.Price, 64)),
+			"quantity":    Unwrap(strconv.ParseFloat(m.Quantity, 64)),
+			"buyer_maker": m.BuyerMaker,
+		},
+		time.UnixMicro(m.Time),
+	)
+
+	return p
+}
+
 // InfluxDBStore stores the data point.
 func (s *Server) InfluxDBStore(object InfluxDBPointer) {
 	if s.dbWriter != nil {
 		s.dbWriter.WritePoint(object.InfluxDBPoint())
 	}
 }
+
+func init() {
+	Sinks["InfluxDB"] = SinkFunc(func(s *Server, m Message) error {
+		s.InfluxDBStore(m)
+		return nil
+	})
+}
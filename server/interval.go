@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// intervalUnits maps interval suffixes to durations.
+var intervalUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// ParseInterval converts the interval name (1s, 5m, 1h, 1d, 1w) to duration.
+func ParseInterval(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid interval '%s'", s)
+	}
+
+	unit, ok := intervalUnits[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid interval '%s'", s)
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid interval '%s'", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// IntervalStart returns the start of the interval (UTC aligned) in microseconds.
+func IntervalStart(t int64, d time.Duration) int64 {
+	return time.UnixMicro(t).UTC().Truncate(d).UnixMicro()
+}
@@ -0,0 +1,14 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	expectDeepEqual(t, Unwrap(ParseInterval("15m")), 15*time.Minute)
+	expectDeepEqual(t, Unwrap(ParseInterval("1d")), 24*time.Hour)
+	if _, err := ParseInterval("1M"); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default histogram buckets in seconds.
+var defaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Escapes of label values in Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue quotes the label value in Prometheus text format.
+func labelValue(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+// metricLabels renders label pairs in Prometheus text format.
+func metricLabels(names []string, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+len(extra)/2)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, labelValue(values[i])))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", extra[i], labelValue(extra[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats the value in Prometheus text format.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// CounterVec is the counter partitioned by labels.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+// NewCounterVec returns the counter with the given label names.
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]uint64)}
+
+	// Counter without labels is exposed from the start
+	if len(labels) == 0 {
+		c.values[""] = 0
+	}
+	return c
+}
+
+// Add increments the counter for the label values.
+func (c *CounterVec) Add(n uint64, values ...string) {
+	key := metricLabels(c.labels, values)
+
+	c.mu.Lock()
+	c.values[key] += n
+	c.mu.Unlock()
+}
+
+// Inc increments the counter for the label values by 1.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the counter value for the label values.
+func (c *CounterVec) Value(values ...string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[metricLabels(c.labels, values)]
+}
+
+// Write writes the counter in Prometheus text format.
+func (c *CounterVec) Write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %d\n", c.name, key, c.values[key])
+	}
+}
+
+type histogram struct {
+	values []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec is the histogram partitioned by labels.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogram
+}
+
+// NewHistogramVec returns the histogram with the given buckets and label names.
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogram)}
+}
+
+// Observe adds the value to the histogram for the label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := metricLabels(h.labels, values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist := h.values[key]
+	if hist == nil {
+		hist = &histogram{values: values, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.sum += v
+	hist.count++
+}
+
+// ObserveDuration adds the duration in seconds to the histogram.
+func (h *HistogramVec) ObserveDuration(d time.Duration, values ...string) {
+	h.Observe(d.Seconds(), values...)
+}
+
+// Write writes the histogram in Prometheus text format.
+func (h *HistogramVec) Write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.values) {
+		hist := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, metricLabels(h.labels, hist.values, "le", formatFloat(bound)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, metricLabels(h.labels, hist.values, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, key, formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, key, hist.count)
+	}
+}
+
+// sortedKeys returns sorted keys of the map.
+func sortedKeys[T any](m map[string]T) []string {
+	r := make([]string, 0, len(m))
+	for k := range m {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// Metrics contains server metrics.
+type Metrics struct {
+	Messages           *CounterVec
+	HandlerErrors      *CounterVec
+	WSReconnects       *CounterVec
+	WSStaleFeeds       *CounterVec
+	NATSPublishErrors  *CounterVec
+	JetStreamErrors    *CounterVec
+	MongoDBWrites      *HistogramVec
+	MongoDBWriteErrors *CounterVec
+	InfluxDBErrors     *CounterVec
+	MessageLatency     *HistogramVec
+}
+
+// NewMetrics returns server metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Messages:           NewCounterVec("stockmq_messages_total", "Messages processed by connection, handler and type.", "connection", "handler", "type"),
+		HandlerErrors:      NewCounterVec("stockmq_handler_errors_total", "Errors returned by WebSocket handlers.", "connection", "handler"),
+		WSReconnects:       NewCounterVec("stockmq_ws_reconnects_total", "WebSocket reconnects.", "connection"),
+		WSStaleFeeds:       NewCounterVec("stockmq_ws_stale_feeds_total", "WebSocket connections recycled without data, symbol is empty for the connection.", "connection", "symbol"),
+		NATSPublishErrors:  NewCounterVec("stockmq_nats_publish_errors_total", "NATS publish errors."),
+		JetStreamErrors:    NewCounterVec("stockmq_nats_jetstream_errors_total", "JetStream publish, ack and timeout errors.", "reason"),
+		MongoDBWrites:      NewHistogramVec("stockmq_mongodb_write_duration_seconds", "MongoDB bulk write latency.", defaultBuckets, "collection"),
+		MongoDBWriteErrors: NewCounterVec("stockmq_mongodb_write_errors_total", "MongoDB bulk write errors.", "collection"),
+		InfluxDBErrors:     NewCounterVec("stockmq_influxdb_write_errors_total", "InfluxDB write errors."),
+		MessageLatency:     NewHistogramVec("stockmq_message_latency_seconds", "Latency from the exchange (TimeSrv) to the server (TimeRcv).", defaultBuckets, "connection", "type"),
+	}
+}
+
+// messageType returns the type of the message.
+func messageType(m Message) string {
+	switch m.(type) {
+	case *Candle:
+		return "candle"
+	case *Quote:
+		return "quote"
+	case *Trade:
+		return "trade"
+	}
+	return "unknown"
+}
+
+// observeMessage updates message counters, latency and the last data time of the connection.
+func (s *Server) observeMessage(m Message) {
+	h := m.Header()
+	kind := messageType(m)
+
+	handler := ""
+	if conn := s.WSConnection(h.Source); conn != nil {
+		handler = conn.Config().Handler
+		conn.touchData(h.Symbol, time.Now())
+	}
+
+	s.metrics.Messages.Inc(h.Source, handler, kind)
+	if h.TimeSrv > 0 && h.TimeRcv >= h.TimeSrv {
+		s.metrics.MessageLatency.ObserveDuration(time.Duration(h.TimeRcv-h.TimeSrv)*time.Microsecond, h.Source, kind)
+	}
+}
+
+// WriteMetrics writes all metrics in Prometheus text format.
+func (s *Server) WriteMetrics(w io.Writer) {
+	m := s.metrics
+	m.Messages.Write(w)
+	m.HandlerErrors.Write(w)
+	m.WSReconnects.Write(w)
+	m.WSStaleFeeds.Write(w)
+	m.NATSPublishErrors.Write(w)
+	m.JetStreamErrors.Write(w)
+	m.MongoDBWrites.Write(w)
+	m.MongoDBWriteErrors.Write(w)
+	m.InfluxDBErrors.Write(w)
+	m.MessageLatency.Write(w)
+
+	// Sink queues
+	stats := s.SinkQueueStats()
+	fmt.Fprintf(w, "# HELP stockmq_sink_queued_total Messages queued to sinks.\n# TYPE stockmq_sink_queued_total counter\n")
+	for _, name := range sortedKeys(stats) {
+		fmt.Fprintf(w, "stockmq_sink_queued_total%s %d\n", metricLabels([]string{"sink"}, []string{name}), stats[name].Queued)
+	}
+	fmt.Fprintf(w, "# HELP stockmq_sink_dropped_total Messages dropped by full sink queues.\n# TYPE stockmq_sink_dropped_total counter\n")
+	for _, name := range sortedKeys(stats) {
+		fmt.Fprintf(w, "stockmq_sink_dropped_total%s %d\n", metricLabels([]string{"sink"}, []string{name}), stats[name].Dropped)
+	}
+	fmt.Fprintf(w, "# HELP stockmq_sink_queue_length Messages waiting in sink queues.\n# TYPE stockmq_sink_queue_length gauge\n")
+	for _, name := range sortedKeys(stats) {
+		fmt.Fprintf(w, "stockmq_sink_queue_length%s %d\n", metricLabels([]string{"sink"}, []string{name}), stats[name].Length)
+	}
+}
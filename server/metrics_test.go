@@ -0,0 +1,51 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("foo_total", "Foo.", "a", "b")
+	c.Inc("x", "y")
+	c.Add(2, "x", "y")
+	c.Inc("x", `"z"`)
+
+	var buf bytes.Buffer
+	c.Write(&buf)
+	expectDeepEqual(t, buf.String(), "# HELP foo_total Foo.\n# TYPE foo_total counter\n"+
+		"foo_total{a=\"x\",b=\"\\\"z\\\"\"} 1\n"+
+		"foo_total{a=\"x\",b=\"y\"} 3\n")
+
+	buf.Reset()
+	NewCounterVec("bar_total", "Bar.").Write(&buf)
+	expectDeepEqual(t, buf.String(), "# HELP bar_total Bar.\n# TYPE bar_total counter\nbar_total 0\n")
+}
+
+func TestMetricLabels(t *testing.T) {
+	expectDeepEqual(t, metricLabels([]string{"a"}, []string{"x\ty\\z\n\"é"}), "{a=\"x\ty\\\\z\\n\\\"é\"}")
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("foo_seconds", "Foo.", []float64{0.1, 1}, "a")
+	h.Observe(0.05, "x")
+	h.Observe(0.5, "x")
+
+	var buf bytes.Buffer
+	h.Write(&buf)
+	expectDeepEqual(t, buf.String(), "# HELP foo_seconds Foo.\n# TYPE foo_seconds histogram\n"+
+		"foo_seconds_bucket{a=\"x\",le=\"0.1\"} 1\n"+
+		"foo_seconds_bucket{a=\"x\",le=\"1\"} 2\n"+
+		"foo_seconds_bucket{a=\"x\",le=\"+Inf\"} 2\n"+
+		"foo_seconds_sum{a=\"x\"} 0.55\n"+
+		"foo_seconds_count{a=\"x\"} 2\n")
+}
+
+func TestObserveMessage(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WebSocket = []WSConfig{{Name: "foo", Enabled: true, Handler: "Binance"}}
+	srv, _ := NewServer(cfg)
+
+	srv.observeMessage(&Candle{MessageHeader: MessageHeader{Source: "foo", TimeSrv: 1000, TimeRcv: 3000}})
+	expectDeepEqual(t, srv.metrics.Messages.Value("foo", "Binance", "candle"), uint64(1))
+}
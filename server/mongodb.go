@@ -2,32 +2,74 @@ package server
 
 import (
 	"context"
-	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MongoDB index Configuration, keys are comma separated fields, "-" prefix for descending order.
+type MongoDBIndexConfig struct {
+	Name   string `xml:"Name,attr"`
+	Unique bool   `xml:"Unique,attr"`
+	Keys   string `xml:",chardata"`
+}
+
+// MongoDB collection Configuration.
+type MongoDBCollectionConfig struct {
+	Name               string               `xml:"Name,attr"`
+	TimeSeries         bool                 `xml:"TimeSeries"`
+	TimeField          string               `xml:"TimeField"`
+	MetaField          string               `xml:"MetaField"`
+	Granularity        string               `xml:"Granularity"`
+	ExpireAfterSeconds int64                `xml:"ExpireAfterSeconds"`
+	Indexes            []MongoDBIndexConfig `xml:"Index"`
+}
+
 // MongoDB Configuration.
 type MongoDBConfig struct {
-	Enabled    bool   `xml:"Enabled"`
-	URL        string `xml:"URL"`
-	RetryDelay int    `xml:"RetryDelay"`
-	Database   string `xml:"Database"`
-	Candles    string `xml:"Candles"`
-	Quotes     string `xml:"Quotes"`
+	Enabled       bool   `xml:"Enabled"`
+	URL           string `xml:"URL"`
+	RetryDelay    int    `xml:"RetryDelay"`
+	Database      string `xml:"Database"`
+	Candles       string `xml:"Candles"`
+	Quotes        string `xml:"Quotes"`
+	Trades        string `xml:"Trades"`
+	BatchSize     int    `xml:"BatchSize"`
+	FlushInterval int    `xml:"FlushInterval"`
+	MaxBuffer     int    `xml:"MaxBuffer"`
+	WriteTimeout  int    `xml:"WriteTimeout"`
+	UpsertCandles bool   `xml:"UpsertCandles"`
+
+	Collections []MongoDBCollectionConfig `xml:"Collection"`
 }
 
 // DefaultMongoDBConfig returns default MongoDB config.
 func DefaultMongoDBConfig() MongoDBConfig {
 	return MongoDBConfig{
-		Enabled:    false,
-		URL:        "mongodb://localhost:27017",
-		RetryDelay: 5,
-		Database:   "stockmq",
-		Candles:    "candles",
-		Quotes:     "quotes",
+		Enabled:       false,
+		URL:           "mongodb://localhost:27017",
+		RetryDelay:    5,
+		Database:      "stockmq",
+		Candles:       "candles",
+		Quotes:        "quotes",
+		Trades:        "trades",
+		BatchSize:     500,
+		FlushInterval: 1000,
+		MaxBuffer:     100000,
+		WriteTimeout:  10,
+		UpsertCandles: false,
+		Collections:   []MongoDBCollectionConfig{},
+	}
+}
+
+// Collection returns configuration of the collection.
+func (c MongoDBConfig) Collection(name string) MongoDBCollectionConfig {
+	for _, cfg := range c.Collections {
+		if cfg.Name == name {
+			return cfg
+		}
 	}
+	return MongoDBCollectionConfig{Name: name}
 }
 
 // InfluxDBConfig returns InfluxDB configuration.
@@ -37,7 +79,7 @@ func (s *Server) MongoDBConfig() MongoDBConfig {
 
 // IsMongoDBReconnecting returns whether MongoDB is scheduled to reconnect.
 func (s *Server) IsMongoDBReconnecting() bool {
-	return s.mongoReconn.Load()
+	return s.mongoReconnect.IsWaiting()
 }
 
 // CloseMongoDB closes the MongoDB connection.
@@ -58,51 +100,49 @@ func (s *Server) StartMongoDB() {
 	cfg := s.MongoDBConfig()
 	s.Noticef("Starting MongoDB connection to %s", cfg.URL)
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(cfg.URL))
+	client, err := mongo.Connect(s.ctx, options.Client().ApplyURI(cfg.URL))
 	if err != nil {
 		s.HandleMongoDBError(err)
+		return
+	}
+
+	// Writes are held until collections and indexes are created, first inserts would create regular collections
+	if err := s.SetupMongoDB(client); err != nil {
+		client.Disconnect(context.TODO())
+		s.HandleMongoDBError(err)
+		return
 	}
 
+	// The server is shutting down while setting up, CloseMongoDB has been called or waits for the lock
+	s.mongoMu.Lock()
+	if s.IsShutdown() {
+		s.mongoMu.Unlock()
+		client.Disconnect(context.TODO())
+		return
+	}
 	s.mongoClient = client
+	s.mongoMu.Unlock()
+	s.mongoReconnect.Connected()
 }
 
-// StartMongoDB starts MongoDB client.
+// HandleMongoDBError handles MongoDB errors.
 func (s *Server) HandleMongoDBError(err error) {
 	// Do nothing if the server is shutting down or MongoDB is reconnecting
-	if s.IsShutdown() || s.IsMongoDBReconnecting() {
+	if s.IsShutdown() {
 		return
 	}
+	s.componentDown(ComponentMongoDB, err)
 
-	// Close MongoDB connection
-	s.Errorf("MongoDB: %v", err)
-	s.CloseMongoDB()
-
-	// Runs goroutine to restart MongoDB connection after RetryDelay
-	go func() {
-		cfg := s.MongoDBConfig()
-		s.ncReconn.Store(true)
-		s.Noticef("MongoDB: Reconnecting to %s in %ds", cfg.URL, cfg.RetryDelay)
-
-		select {
-		case <-s.quitCh:
-			return
-		case <-time.After(time.Duration(cfg.RetryDelay) * time.Second):
-			s.mongoReconn.Store(false)
-			s.StartMongoDB()
-		}
-	}()
+	// Close MongoDB connection and restart it with backoff
+	s.mongoReconnect.Fail(err)
 }
 
-// MongoDBStore persists the object in MongoDB collection
+// MongoDBStore adds the object to the batch of MongoDB collection, full batch is flushed.
 func (s *Server) MongoDBStore(object interface{}) {
 	cfg := s.MongoDBConfig()
-
-	s.mongoMu.Lock()
-	client := s.mongoClient
-	s.mongoMu.Unlock()
+	if !cfg.Enabled {
+		return
+	}
 
 	var c string
 
@@ -111,14 +151,26 @@ func (s *Server) MongoDBStore(object interface{}) {
 		c = cfg.Candles
 	case *Quote:
 		c = cfg.Quotes
+	case *Trade:
+		c = cfg.Trades
 	default:
 		return
 	}
 
-	if client != nil {
-		collection := client.Database(cfg.Database).Collection(c)
-		if _, err := collection.InsertOne(context.TODO(), object); err != nil {
-			s.HandleMongoDBError(err)
-		}
+	w, err := mongoWriteFor(cfg, cfg.Collection(c), object)
+	if err != nil {
+		s.Errorf("MongoDB: %v", err)
+		return
 	}
+
+	if s.mongoBatchAdd(c, w, cfg.MaxBuffer) >= cfg.BatchSize {
+		s.MongoDBFlush(c)
+	}
+}
+
+func init() {
+	Sinks["MongoDB"] = SinkFunc(func(s *Server, m Message) error {
+		s.MongoDBStore(m)
+		return nil
+	})
 }
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoWrite is the buffered write, writes with the same key replace each other.
+type mongoWrite struct {
+	key   string
+	model mongo.WriteModel
+}
+
+// mongoDocument converts the object to the document, time and meta fields are added if configured.
+func mongoDocument(cfg MongoDBCollectionConfig, object interface{}) (bson.D, error) {
+	b, err := bson.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bson.D{}
+	if err := bson.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	if m, ok := object.(Message); ok {
+		h := m.Header()
+		if cfg.TimeField != "" {
+			doc = append(doc, bson.E{Key: cfg.TimeField, Value: primitive.NewDateTimeFromTime(time.UnixMicro(h.Time))})
+		}
+		if cfg.MetaField != "" {
+			doc = append(doc, bson.E{Key: cfg.MetaField, Value: bson.D{{Key: "symbol", Value: h.Symbol}, {Key: "source", Value: h.Source}}})
+		}
+	}
+	return doc, nil
+}
+
+// mongoCandleFilter returns the filter matching the candle by symbol, source, interval and time.
+func mongoCandleFilter(c *Candle) bson.D {
+	return bson.D{
+		{Key: "messageheader.symbol", Value: c.Symbol},
+		{Key: "messageheader.source", Value: c.Source},
+		{Key: "interval", Value: c.Interval},
+		{Key: "messageheader.time", Value: c.Time},
+	}
+}
+
+// mongoWriteFor returns the insert of the object or the upsert of the candle.
+func mongoWriteFor(cfg MongoDBConfig, collection MongoDBCollectionConfig, object interface{}) (mongoWrite, error) {
+	doc, err := mongoDocument(collection, object)
+	if err != nil {
+		return mongoWrite{}, err
+	}
+
+	// Time series collections do not support replacing documents
+	if c, ok := object.(*Candle); ok && cfg.UpsertCandles && !collection.TimeSeries {
+		return mongoWrite{
+			key:   fmt.Sprintf("%s.%s.%s.%d", c.Symbol, c.Source, c.Interval, c.Time),
+			model: mongo.NewReplaceOneModel().SetFilter(mongoCandleFilter(c)).SetReplacement(doc).SetUpsert(true),
+		}, nil
+	}
+
+	// Generated _id prevents duplicates when the batch is retried
+	doc = append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, doc...)
+	return mongoWrite{model: mongo.NewInsertOneModel().SetDocument(doc)}, nil
+}
+
+// mongoModels returns write models, only the latest write with the same key is kept.
+func mongoModels(batch []mongoWrite) []mongo.WriteModel {
+	last := make(map[string]int)
+	for i, w := range batch {
+		if w.key != "" {
+			last[w.key] = i
+		}
+	}
+
+	r := make([]mongo.WriteModel, 0, len(batch))
+	for i, w := range batch {
+		if w.key == "" || last[w.key] == i {
+			r = append(r, w.model)
+		}
+	}
+	return r
+}
+
+// mongoBatchAdd appends the write to the collection buffer and returns its length.
+func (s *Server) mongoBatchAdd(collection string, w mongoWrite, limit int) int {
+	s.mongoBatchMu.Lock()
+	defer s.mongoBatchMu.Unlock()
+
+	if s.mongoBatches == nil {
+		s.mongoBatches = make(map[string][]mongoWrite)
+	}
+
+	batch := append(s.mongoBatches[collection], w)
+	if limit > 0 && len(batch) > limit {
+		s.Warnf("MongoDB: %s buffer is full, dropping %d documents", collection, len(batch)-limit)
+		batch = batch[len(batch)-limit:]
+	}
+	s.mongoBatches[collection] = batch
+
+	return len(batch)
+}
+
+// mongoBatchTake removes up to n writes from the collection buffer.
+func (s *Server) mongoBatchTake(collection string, n int) []mongoWrite {
+	s.mongoBatchMu.Lock()
+	defer s.mongoBatchMu.Unlock()
+
+	batch := s.mongoBatches[collection]
+	if n < 1 || n > len(batch) {
+		n = len(batch)
+	}
+
+	r := batch[:n:n]
+	s.mongoBatches[collection] = batch[n:]
+	return r
+}
+
+// mongoBatchReturn puts the failed batch back to the front of the collection buffer,
+// writes superseded by a newer write with the same key are dropped.
+func (s *Server) mongoBatchReturn(collection string, docs []mongoWrite) {
+	s.mongoBatchMu.Lock()
+	defer s.mongoBatchMu.Unlock()
+
+	newer := make(map[string]bool)
+	for _, w := range s.mongoBatches[collection] {
+		if w.key != "" {
+			newer[w.key] = true
+		}
+	}
+
+	batch := make([]mongoWrite, 0, len(docs)+len(s.mongoBatches[collection]))
+	for _, w := range docs {
+		if w.key == "" || !newer[w.key] {
+			batch = append(batch, w)
+		}
+	}
+	s.mongoBatches[collection] = append(batch, s.mongoBatches[collection]...)
+}
+
+// mongoFlushLock returns the lock which serializes flushes of the collection.
+func (s *Server) mongoFlushLock(collection string) *sync.Mutex {
+	s.mongoBatchMu.Lock()
+	defer s.mongoBatchMu.Unlock()
+
+	if s.mongoFlushMu == nil {
+		s.mongoFlushMu = make(map[string]*sync.Mutex)
+	}
+	mu := s.mongoFlushMu[collection]
+	if mu == nil {
+		mu = &sync.Mutex{}
+		s.mongoFlushMu[collection] = mu
+	}
+	return mu
+}
+
+// mongoWriteBatch writes the models to the collection.
+func (s *Server) mongoWriteBatch(ctx context.Context, collection *mongo.Collection, models []mongo.WriteModel) error {
+	if s.mongoBulkWrite != nil {
+		return s.mongoBulkWrite(ctx, collection, models)
+	}
+	_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// MongoDBFlush writes buffered documents of the collection, failed batches are kept for retry.
+func (s *Server) MongoDBFlush(collection string) error {
+	cfg := s.MongoDBConfig()
+
+	// Batches of the collection are written in order by the sink and the flusher
+	mu := s.mongoFlushLock(collection)
+	mu.Lock()
+	defer mu.Unlock()
+
+	for {
+		s.mongoMu.RLock()
+		client := s.mongoClient
+		s.mongoMu.RUnlock()
+
+		// Keep the documents until connected
+		if client == nil {
+			return nil
+		}
+
+		batch := s.mongoBatchTake(collection, cfg.BatchSize)
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.WriteTimeout)*time.Second)
+		start := time.Now()
+		err := s.mongoWriteBatch(ctx, client.Database(cfg.Database).Collection(collection), mongoModels(batch))
+		cancel()
+
+		s.metrics.MongoDBWrites.ObserveDuration(time.Since(start), collection)
+		if err != nil {
+			s.metrics.MongoDBWriteErrors.Inc(collection)
+
+			// Documents rejected by the server (e.g. already written by the previous attempt) are not retried
+			var bwe mongo.BulkWriteException
+			if errors.As(err, &bwe) && bwe.WriteConcernError == nil {
+				s.Warnf("MongoDB: %d of %d documents rejected by %s: %v", len(bwe.WriteErrors), len(batch), collection, err)
+				continue
+			}
+
+			s.mongoBatchReturn(collection, batch)
+			s.HandleMongoDBError(err)
+			return err
+		}
+	}
+}
+
+// FlushMongoDB writes buffered documents of all collections.
+func (s *Server) FlushMongoDB() error {
+	s.mongoBatchMu.Lock()
+	collections := make([]string, 0, len(s.mongoBatches))
+	for c := range s.mongoBatches {
+		collections = append(collections, c)
+	}
+	s.mongoBatchMu.Unlock()
+
+	var r error
+	for _, c := range collections {
+		if err := s.MongoDBFlush(c); err != nil {
+			r = err
+		}
+	}
+	return r
+}
+
+// MongoDBFlusher runs goroutine to flush buffered documents every FlushInterval.
+func (s *Server) MongoDBFlusher() {
+	interval := time.Duration(s.MongoDBConfig().FlushInterval) * time.Millisecond
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.FlushMongoDB()
+			case <-s.quitCh:
+				return
+			}
+		}
+	}()
+}
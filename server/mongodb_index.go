@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoDB error code returned when the index exists with different options.
+const mongoIndexOptionsConflict = 85
+
+// MongoDBIndexKeys parses comma separated keys, "-" prefix means descending order.
+func MongoDBIndexKeys(keys string) (bson.D, error) {
+	r := bson.D{}
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		order := 1
+		if strings.HasPrefix(key, "-") {
+			key = key[1:]
+			order = -1
+		}
+		if key == "" {
+			return nil, fmt.Errorf("invalid index keys '%s'", keys)
+		}
+		r = append(r, bson.E{Key: key, Value: order})
+	}
+	return r, nil
+}
+
+// mongoCollectionType returns the type of the collection or empty string if it does not exist.
+func mongoCollectionType(ctx context.Context, db *mongo.Database, name string) (string, error) {
+	specs, err := db.ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: name}})
+	if err != nil {
+		return "", err
+	}
+	if len(specs) == 0 {
+		return "", nil
+	}
+	return specs[0].Type, nil
+}
+
+// setupMongoDBCollection creates the collection, TTL and indexes if needed.
+func (s *Server) setupMongoDBCollection(ctx context.Context, db *mongo.Database, cfg MongoDBCollectionConfig) error {
+	kind, err := mongoCollectionType(ctx, db, cfg.Name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case kind == "" && cfg.TimeSeries:
+		if cfg.TimeField == "" {
+			return fmt.Errorf("time series collection %s requires TimeField", cfg.Name)
+		}
+
+		ts := options.TimeSeries().SetTimeField(cfg.TimeField)
+		if cfg.MetaField != "" {
+			ts.SetMetaField(cfg.MetaField)
+		}
+		if cfg.Granularity != "" {
+			ts.SetGranularity(cfg.Granularity)
+		}
+
+		opts := options.CreateCollection().SetTimeSeriesOptions(ts)
+		if cfg.ExpireAfterSeconds > 0 {
+			opts.SetExpireAfterSeconds(cfg.ExpireAfterSeconds)
+		}
+
+		s.Noticef("MongoDB: creating time series collection %s", cfg.Name)
+		if err := db.CreateCollection(ctx, cfg.Name, opts); err != nil {
+			return err
+		}
+	case kind == "timeseries":
+		if cfg.ExpireAfterSeconds > 0 {
+			cmd := bson.D{{Key: "collMod", Value: cfg.Name}, {Key: "expireAfterSeconds", Value: cfg.ExpireAfterSeconds}}
+			if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+				return err
+			}
+		}
+	default:
+		if cfg.TimeSeries {
+			s.Warnf("MongoDB: %s exists and is not a time series collection", cfg.Name)
+		}
+
+		// TTL index on the time field for regular collections
+		if cfg.ExpireAfterSeconds > 0 {
+			if cfg.TimeField == "" {
+				return fmt.Errorf("collection %s requires TimeField for ExpireAfterSeconds", cfg.Name)
+			}
+
+			keys := bson.D{{Key: cfg.TimeField, Value: 1}}
+			index := mongo.IndexModel{
+				Keys:    keys,
+				Options: options.Index().SetName(cfg.TimeField + "_ttl").SetExpireAfterSeconds(int32(cfg.ExpireAfterSeconds)),
+			}
+
+			if _, err := db.Collection(cfg.Name).Indexes().CreateOne(ctx, index); err != nil {
+				var ce mongo.CommandError
+				if !errors.As(err, &ce) || !ce.HasErrorCode(mongoIndexOptionsConflict) {
+					return err
+				}
+
+				// Update retention of the existing index
+				cmd := bson.D{
+					{Key: "collMod", Value: cfg.Name},
+					{Key: "index", Value: bson.D{{Key: "keyPattern", Value: keys}, {Key: "expireAfterSeconds", Value: cfg.ExpireAfterSeconds}}},
+				}
+				if err := db.RunCommand(ctx, cmd).Err(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Indexes
+	for _, idx := range cfg.Indexes {
+		keys, err := MongoDBIndexKeys(idx.Keys)
+		if err != nil {
+			return err
+		}
+
+		opts := options.Index()
+		if idx.Name != "" {
+			opts.SetName(idx.Name)
+		}
+		if idx.Unique {
+			opts.SetUnique(true)
+		}
+
+		if _, err := db.Collection(cfg.Name).Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys, Options: opts}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mongoDBSetupError logs the error and returns it if the connection is lost.
+func (s *Server) mongoDBSetupError(name string, err error) error {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return err
+	}
+
+	s.Errorf("MongoDB: cannot setup %s: %v", name, err)
+	return nil
+}
+
+// SetupMongoDB creates the collections and indexes, returns network errors.
+func (s *Server) SetupMongoDB(client *mongo.Client) error {
+	cfg := s.MongoDBConfig()
+	db := client.Database(cfg.Database)
+
+	ctx, cancel := context.WithTimeout(s.ctx, time.Duration(cfg.WriteTimeout)*time.Second)
+	defer cancel()
+
+	for _, c := range cfg.Collections {
+		if err := s.setupMongoDBCollection(ctx, db, c); err != nil {
+			if err := s.mongoDBSetupError(c.Name, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.UpsertCandles {
+		// Time series collections do not support unique indexes
+		if cfg.Collection(cfg.Candles).TimeSeries {
+			s.Warnf("MongoDB: UpsertCandles is ignored for time series collection %s", cfg.Candles)
+			return nil
+		}
+
+		index := mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "messageheader.symbol", Value: 1},
+				{Key: "messageheader.source", Value: 1},
+				{Key: "interval", Value: 1},
+				{Key: "messageheader.time", Value: 1},
+			},
+			Options: options.Index().SetName("symbol_source_interval_time").SetUnique(true),
+		}
+
+		if _, err := db.Collection(cfg.Candles).Indexes().CreateOne(ctx, index); err != nil {
+			return s.mongoDBSetupError(cfg.Candles, err)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,158 @@
 package server
 
-import "testing"
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
 
 func TestMongoDBConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	srv, _ := NewServer(DefaultConfig())
 	expectDeepEqual(t, srv.MongoDBConfig(), cfg.MongoDB)
 }
+
+func TestMongoDBDocument(t *testing.T) {
+	doc, err := mongoDocument(MongoDBCollectionConfig{}, &Candle{MessageHeader: MessageHeader{Symbol: "foo"}, Interval: "1m"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, doc[0].Key, "messageheader")
+	expectDeepEqual(t, doc[1].Key, "interval")
+}
+
+func TestMongoDBUpsert(t *testing.T) {
+	cfg := DefaultMongoDBConfig()
+	cfg.UpsertCandles = true
+
+	c := &Candle{MessageHeader: MessageHeader{Symbol: "foo", Source: "bar", Time: 1}, Interval: "1m"}
+	first := Unwrap(mongoWriteFor(cfg, MongoDBCollectionConfig{}, c))
+	c.Final = true
+	last := Unwrap(mongoWriteFor(cfg, MongoDBCollectionConfig{}, c))
+	insert := Unwrap(mongoWriteFor(cfg, MongoDBCollectionConfig{}, &Quote{}))
+
+	expectDeepEqual(t, first.key, "foo.bar.1m.1")
+	expectDeepEqual(t, mongoModels([]mongoWrite{first, insert, last}), []mongo.WriteModel{insert.model, last.model})
+}
+
+func TestMongoDBBatch(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+	w := func(i int) mongoWrite {
+		return mongoWrite{key: strconv.Itoa(i)}
+	}
+
+	for i := 0; i < 5; i++ {
+		srv.mongoBatchAdd("candles", w(i), 4)
+	}
+
+	batch := srv.mongoBatchTake("candles", 3)
+	expectDeepEqual(t, batch, []mongoWrite{w(1), w(2), w(3)})
+
+	srv.mongoBatchReturn("candles", batch)
+	expectDeepEqual(t, srv.mongoBatchTake("candles", 0), []mongoWrite{w(1), w(2), w(3), w(4)})
+
+	// Retried writes are dropped if a newer write has the same key
+	srv.mongoBatchAdd("candles", w(2), 4)
+	srv.mongoBatchReturn("candles", []mongoWrite{w(1), w(2), {}})
+	expectDeepEqual(t, srv.mongoBatchTake("candles", 0), []mongoWrite{w(1), {}, w(2)})
+
+	// Writes are kept while disconnected
+	srv.mongoBatchAdd("candles", w(5), 4)
+	if err := srv.MongoDBFlush("candles"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectDeepEqual(t, srv.mongoBatchTake("candles", 0), []mongoWrite{w(5)})
+}
+
+func TestMongoDBIndexKeys(t *testing.T) {
+	keys, err := MongoDBIndexKeys("meta.symbol, -timestamp")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectDeepEqual(t, keys, bson.D{{Key: "meta.symbol", Value: 1}, {Key: "timestamp", Value: -1}})
+
+	if _, err := MongoDBIndexKeys("symbol,,time"); err == nil {
+		t.Fatalf("Expected error for empty key")
+	}
+}
+
+func TestMongoDBTimeSeriesDocument(t *testing.T) {
+	cfg := MongoDBCollectionConfig{Name: "quotes", TimeSeries: true, TimeField: "timestamp", MetaField: "meta"}
+	doc, err := mongoDocument(cfg, &Quote{MessageHeader: MessageHeader{Symbol: "foo", Source: "bar", Time: 1500}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	n := len(doc)
+	expectDeepEqual(t, doc[n-2], bson.E{Key: "timestamp", Value: primitive.DateTime(1)})
+	expectDeepEqual(t, doc[n-1], bson.E{Key: "meta", Value: bson.D{{Key: "symbol", Value: "foo"}, {Key: "source", Value: "bar"}}})
+}
+
+func TestMongoDBFlushOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MongoDB.Enabled = true
+	cfg.MongoDB.BatchSize = 2
+	srv, _ := NewServer(cfg)
+
+	client := Unwrap(mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1")))
+	defer client.Disconnect(context.Background())
+	srv.mongoClient = client
+
+	// Records written times, the first write is held to overlap with the next flush
+	var mu sync.Mutex
+	var inflight atomic.Int32
+	written := []int64{}
+	started := make(chan struct{})
+	var once sync.Once
+	srv.mongoBulkWrite = func(ctx context.Context, c *mongo.Collection, models []mongo.WriteModel) error {
+		if inflight.Add(1) > 1 {
+			t.Errorf("Concurrent flush of %s", c.Name())
+		}
+		defer inflight.Add(-1)
+		once.Do(func() {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range models {
+			doc := m.(*mongo.InsertOneModel).Document.(bson.D)
+			header := doc[1].Value.(bson.D)
+			for _, e := range header {
+				if e.Key == "time" {
+					written = append(written, e.Value.(int64))
+				}
+			}
+		}
+		return nil
+	}
+
+	// The flusher writes a partial batch while the sink fills a full one
+	srv.MongoDBStore(&Candle{MessageHeader: MessageHeader{Time: 0}})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.FlushMongoDB()
+	}()
+	<-started
+	for i := 1; i < 5; i++ {
+		srv.MongoDBStore(&Candle{MessageHeader: MessageHeader{Time: int64(i)}})
+	}
+	<-done
+	srv.FlushMongoDB()
+
+	expectDeepEqual(t, len(written), 5)
+	for i, v := range written {
+		expectDeepEqual(t, v, int64(i))
+	}
+}
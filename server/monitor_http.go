@@ -1,28 +1,35 @@
 package server
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
+	"net"
 	"net/http"
-	"os"
 )
 
 // Monitor Configuration
 type MonitorConfig struct {
-	Bind           string   `xml:"Bind"`
-	TLS            bool     `xml:"TLS"`
-	TLSCertificate string   `xml:"TLSCertificate"`
-	TLSKey         string   `xml:"TLSKey"`
-	Headers        []Header `xml:"Header"`
+	Bind            string   `xml:"Bind"`
+	TLS             bool     `xml:"TLS"`
+	TLSCertificate  string   `xml:"TLSCertificate"`
+	TLSKey          string   `xml:"TLSKey"`
+	Headers         []Header `xml:"Header"`
+	Required        []string `xml:"Required"`
+	LivenessTimeout int      `xml:"LivenessTimeout"`
 }
 
 // DefaultMonitorConfig returns default Monitor config
 func DefaultMonitorConfig() MonitorConfig {
 	return MonitorConfig{
-		Bind:           "127.0.0.1:9100",
-		TLS:            false,
-		TLSCertificate: "",
-		TLSKey:         "",
+		Bind:            "127.0.0.1:9100",
+		TLS:             false,
+		TLSCertificate:  "",
+		TLSKey:          "",
+		Required:        []string{},
+		LivenessTimeout: 60,
 	}
 }
 
@@ -34,6 +41,10 @@ func (s *Server) MonitorConfig() MonitorConfig {
 const (
 	LivezEndpoint  = "/livez"
 	ReadyzEndpoint = "/readyz"
+	SinkzEndpoint  = "/sinkz"
+
+	SubscriptionsEndpoint = "/subscriptions"
+	MetricsEndpoint       = "/metrics"
 )
 
 // ResponseHandler handles responses for monitor routes (JSONP and JSON).
@@ -51,7 +62,8 @@ func (s *Server) ResponseHandler(w http.ResponseWriter, r *http.Request, code in
 
 	w.WriteHeader(code)
 
-	if callback := r.URL.Query().Get("callback"); callback != "" {
+	// JSONP is served only for reads
+	if callback := r.URL.Query().Get("callback"); callback != "" && r.Method == http.MethodGet {
 		w.Header().Set("Content-Type", "application/javascript")
 		fmt.Fprintf(w, "%s(%s)", callback, b)
 	} else {
@@ -60,55 +72,130 @@ func (s *Server) ResponseHandler(w http.ResponseWriter, r *http.Request, code in
 	}
 }
 
-// HandleLivez returns liveness check.
+// HandleLivez returns liveness check, the server and its workers are healthy.
 func (s *Server) HandleLivez(w http.ResponseWriter, r *http.Request) {
-	if status := s.healthStatus(); status.Error == "" {
+	if status := s.livenessStatus(); status.Error == "" {
 		s.ResponseHandler(w, r, http.StatusOK, status)
 	} else {
 		s.ResponseHandler(w, r, http.StatusServiceUnavailable, status)
 	}
 }
 
-// HandleReadyz returns readiness check.
+// HandleReadyz returns readiness check, the startup is complete and required components are connected.
 func (s *Server) HandleReadyz(w http.ResponseWriter, r *http.Request) {
-	v := &HealthStatus{Status: "ok"}
-	s.ResponseHandler(w, r, http.StatusOK, v)
+	if status := s.readinessStatus(); status.Error == "" {
+		s.ResponseHandler(w, r, http.StatusOK, status)
+	} else {
+		s.ResponseHandler(w, r, http.StatusServiceUnavailable, status)
+	}
+}
+
+// HandleSinkz returns sink queue counters.
+func (s *Server) HandleSinkz(w http.ResponseWriter, r *http.Request) {
+	s.ResponseHandler(w, r, http.StatusOK, s.SinkQueueStats())
+}
+
+// SubscriptionsStatus represents runtime subscriptions of the connection.
+type SubscriptionsStatus struct {
+	Connection string   `json:"connection"`
+	Streams    []string `json:"streams"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// HandleSubscriptions lists (GET), adds (POST) or removes (DELETE) subscriptions of the connection.
+// Parameters: connection=<name>&stream=<stream>&stream=<stream>.
+func (s *Server) HandleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("connection")
+	streams := r.URL.Query()["stream"]
+
+	conn := s.WSConnection(name)
+	if conn == nil {
+		s.ResponseHandler(w, r, http.StatusNotFound, &SubscriptionsStatus{Connection: name, Error: "connection not found"})
+		return
+	}
+
+	// Changes require JSON requests which cannot be sent by forms or script tags
+	if r.Method == http.MethodPost || r.Method == http.MethodDelete {
+		if r.URL.Query().Has("callback") {
+			s.ResponseHandler(w, r, http.StatusBadRequest, &SubscriptionsStatus{Connection: name, Error: "callback is not allowed"})
+			return
+		}
+		if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t != "application/json" {
+			s.ResponseHandler(w, r, http.StatusUnsupportedMediaType, &SubscriptionsStatus{Connection: name, Error: "Content-Type must be application/json"})
+			return
+		}
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		err = s.WSSubscribe(conn, streams)
+	case http.MethodDelete:
+		err = s.WSUnsubscribe(conn, streams)
+	default:
+		s.ResponseHandler(w, r, http.StatusMethodNotAllowed, &SubscriptionsStatus{Connection: name, Error: "method not allowed"})
+		return
+	}
+
+	if err != nil {
+		s.ResponseHandler(w, r, http.StatusBadRequest, &SubscriptionsStatus{Connection: name, Error: err.Error()})
+		return
+	}
+	s.ResponseHandler(w, r, http.StatusOK, &SubscriptionsStatus{Connection: name, Streams: conn.Subscriptions()})
 }
 
-// StartMonitor starts the HTTP or HTTPs server if needed.
-func (s *Server) StartMonitor() {
+// HandleMetrics returns metrics in Prometheus text format.
+func (s *Server) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.WriteMetrics(w)
+}
+
+// StartMonitor starts the HTTP or HTTPs server, bind errors are returned.
+func (s *Server) StartMonitor() error {
 	cfg := s.MonitorConfig()
 	s.Noticef("Starting Monitor on %v tls: %v", cfg.Bind, cfg.TLS)
 
+	srv := &http.Server{
+		Addr: cfg.Bind,
+	}
+
+	if cfg.TLS {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertificate, cfg.TLSKey)
+		if err != nil {
+			return fmt.Errorf("Monitor: cannot load TLS certificate: %v", err)
+		}
+		srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	listener, err := net.Listen("tcp", cfg.Bind)
+	if err != nil {
+		return fmt.Errorf("Monitor: cannot listen on %s: %v", cfg.Bind, err)
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc(LivezEndpoint, s.HandleLivez)
 	mux.HandleFunc(ReadyzEndpoint, s.HandleReadyz)
-
-	srv := &http.Server{
-		Addr:    cfg.Bind,
-		Handler: mux,
-	}
+	mux.HandleFunc(SinkzEndpoint, s.HandleSinkz)
+	mux.HandleFunc(SubscriptionsEndpoint, s.HandleSubscriptions)
+	mux.HandleFunc(MetricsEndpoint, s.HandleMetrics)
+	srv.Handler = mux
 
 	s.mu.Lock()
 	s.monitorServer = srv
 	s.mu.Unlock()
 
 	go func() {
-		serve := func() error {
-			if cfg.TLS {
-				return srv.ListenAndServeTLS(cfg.TLSCertificate, cfg.TLSKey)
-			} else {
-				return srv.ListenAndServe()
-			}
+		var err error
+		if cfg.TLS {
+			err = srv.ServeTLS(listener, "", "")
+		} else {
+			err = srv.Serve(listener)
 		}
 
-		if err := serve(); err != nil {
-			if !s.IsShutdown() {
-				s.Errorf("Monitor: error starting monitor (FATAL): %v", err)
-
-				// TODO (nusov): cancel Start() and close all open connections before exit
-				os.Exit(1)
-			}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.Fatal(fmt.Errorf("Monitor: error serving: %v", err))
 		}
 	}()
+	return nil
 }
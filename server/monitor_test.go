@@ -1,9 +1,44 @@
 package server
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
 
 func TestMonitorConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	srv, _ := NewServer(DefaultConfig())
 	expectDeepEqual(t, srv.MonitorConfig(), cfg.Monitor)
 }
+
+func TestHandleSubscriptions(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+	conn := Unwrap(srv.newWSConnection(WSConfig{Name: "foo", Handler: "Binance"}))
+	srv.wsConnections = map[string]*WSConnection{"foo": conn}
+
+	request := func(method string, query string, contentType string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, SubscriptionsEndpoint+"?connection=foo&stream=btcusdt@trade"+query, nil)
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		srv.HandleSubscriptions(w, r)
+		return w
+	}
+
+	// Changes are accepted only as JSON requests without callback
+	expectDeepEqual(t, request(http.MethodPost, "", "").Code, http.StatusUnsupportedMediaType)
+	expectDeepEqual(t, request(http.MethodPost, "", "application/x-www-form-urlencoded").Code, http.StatusUnsupportedMediaType)
+	expectDeepEqual(t, request(http.MethodPost, "&callback=cb", "application/json").Code, http.StatusBadRequest)
+	expectDeepEqual(t, request(http.MethodDelete, "&callback=cb", "application/json").Code, http.StatusBadRequest)
+	expectDeepEqual(t, conn.Subscriptions(), []string{})
+
+	w := request(http.MethodPost, "", "application/json; charset=utf-8")
+	expectDeepEqual(t, w.Code, http.StatusOK)
+	expectDeepEqual(t, w.Body.String(), `{"connection":"foo","streams":["btcusdt@trade"]}`)
+
+	// Reads still support JSONP
+	w = request(http.MethodGet, "&callback=cb", "")
+	expectDeepEqual(t, w.Body.String(), `cb({"connection":"foo","streams":["btcusdt@trade"]})`)
+}
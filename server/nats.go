@@ -1,19 +1,41 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
-// NATS Configuration
+// NATS Configuration, URL and Servers are used for cluster failover, intervals are in seconds.
 type NATSConfig struct {
-	Name        string `xml:"Name"`
-	URL         string `xml:"URL"`
-	RetryDelay  int    `xml:"RetryDelay"`
-	NoReconnect bool   `xml:"NoReconnect"`
+	Name          string   `xml:"Name"`
+	URL           string   `xml:"URL"`
+	Servers       []string `xml:"Server"`
+	RetryDelay    int      `xml:"RetryDelay"`
+	NoReconnect   bool     `xml:"NoReconnect"`
+	ReconnectWait int      `xml:"ReconnectWait"`
+	MaxReconnects int      `xml:"MaxReconnects"`
+	PingInterval  int      `xml:"PingInterval"`
+
+	// Authentication
+	User        string `xml:"User"`
+	Password    string `xml:"Password"`
+	Token       string `xml:"Token"`
+	NKeySeed    string `xml:"NKeySeed"`
+	Credentials string `xml:"Credentials"`
+
+	// TLS with custom CA and client certificate
+	TLS            bool   `xml:"TLS"`
+	TLSCA          string `xml:"TLSCA"`
+	TLSCertificate string `xml:"TLSCertificate"`
+	TLSKey         string `xml:"TLSKey"`
+
+	JetStream JetStreamConfig `xml:"JetStream"`
 }
 
 // NATSSubjecter provided methods to generate subjects from entities.
@@ -24,10 +46,15 @@ type NATSSubjecter interface {
 // DefaultNATSConfig returns default NATS config
 func DefaultNATSConfig() NATSConfig {
 	return NATSConfig{
-		Name:        "StockMQ",
-		URL:         "nats://127.0.0.1:4222",
-		RetryDelay:  5,
-		NoReconnect: false,
+		Name:          "StockMQ",
+		URL:           "nats://127.0.0.1:4222",
+		Servers:       []string{},
+		RetryDelay:    5,
+		NoReconnect:   false,
+		ReconnectWait: 2,
+		MaxReconnects: 60,
+		PingInterval:  120,
+		JetStream:     DefaultJetStreamConfig(),
 	}
 }
 
@@ -41,7 +68,12 @@ func (m *Candle) NATSSubject() string {
 	return fmt.Sprintf("C.%s.%s.%s", m.Interval, m.Symbol, m.Source)
 }
 
-// NATSSubject returns the subject for the candle message
+// NATSSubject returns the subject for the trade message
+func (m *Trade) NATSSubject() string {
+	return fmt.Sprintf("T.%s.%s", m.Symbol, m.Source)
+}
+
+// NATSSubject returns the subject for the quote message
 func (m *Quote) NATSSubject() string {
 	return fmt.Sprintf("Q.%s.%s", m.Symbol, m.Source)
 }
@@ -51,19 +83,44 @@ func (s *Server) StartNATS() {
 	cfg := s.NATSConfig()
 	s.Noticef("Starting NATS connection to %s", cfg.URL)
 
-	nc, err := nats.Connect(cfg.URL, cfg.NATSOptions()...)
+	options, err := cfg.NATSOptions()
 	if err != nil {
 		s.HandleNATSError(err)
 		return
 	}
 
+	nc, err := nats.Connect(cfg.ServerURLs(), options...)
+	if err != nil {
+		s.HandleNATSError(err)
+		return
+	}
+
+	// Streams are provisioned before publishing
+	var js *jetStreamPublisher
+	if cfg.JetStream.Enabled {
+		if js, err = s.SetupJetStream(nc); err != nil {
+			nc.Close()
+			s.HandleNATSError(err)
+			return
+		}
+	}
+
 	s.ncMu.Lock()
 	s.ncConn = nc
+	s.ncJS = js
 	s.ncMu.Unlock()
+
+	s.natsReconnect.Connected()
+	s.componentUp(ComponentNATS)
+}
+
+// ServerURLs returns comma separated URLs of the cluster.
+func (c *NATSConfig) ServerURLs() string {
+	return strings.Join(append([]string{c.URL}, c.Servers...), ",")
 }
 
 // NATSOptions returns a list of NATS connection options.
-func (c *NATSConfig) NATSOptions() []nats.Option {
+func (c *NATSConfig) NATSOptions() ([]nats.Option, error) {
 	options := []nats.Option{}
 	if c.Name != "" {
 		options = append(options, nats.Name(c.Name))
@@ -71,62 +128,147 @@ func (c *NATSConfig) NATSOptions() []nats.Option {
 	if c.NoReconnect {
 		options = append(options, nats.NoReconnect())
 	}
-	return options
+	if c.ReconnectWait > 0 {
+		options = append(options, nats.ReconnectWait(time.Duration(c.ReconnectWait)*time.Second))
+	}
+	if c.MaxReconnects != 0 {
+		options = append(options, nats.MaxReconnects(c.MaxReconnects))
+	}
+	if c.PingInterval > 0 {
+		options = append(options, nats.PingInterval(time.Duration(c.PingInterval)*time.Second))
+	}
+
+	// Authentication
+	if c.User != "" {
+		options = append(options, nats.UserInfo(c.User, c.Password))
+	}
+	if c.Token != "" {
+		options = append(options, nats.Token(c.Token))
+	}
+	if c.NKeySeed != "" {
+		option, err := nats.NkeyOptionFromSeed(c.NKeySeed)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load NKey seed: %v", err)
+		}
+		options = append(options, option)
+	}
+	if c.Credentials != "" {
+		options = append(options, nats.UserCredentials(c.Credentials))
+	}
+
+	// TLS
+	if c.TLS {
+		options = append(options, nats.Secure())
+	}
+	if c.TLSCA != "" {
+		options = append(options, nats.RootCAs(c.TLSCA))
+	}
+	if c.TLSCertificate != "" || c.TLSKey != "" {
+		options = append(options, nats.ClientCert(c.TLSCertificate, c.TLSKey))
+	}
+	return options, nil
 }
 
 // IsNATSReconnecting returns whether NATS is scheduled to reconnect.
 func (s *Server) IsNATSReconnecting() bool {
-	return s.ncReconn.Load()
+	return s.natsReconnect.IsWaiting()
 }
 
 // CloseNATS closes the NATS connection.
 func (s *Server) CloseNATS() {
 	s.ncMu.Lock()
 	defer s.ncMu.Unlock()
+	if s.ncJS != nil {
+		s.ncJS.close()
+	}
 	if s.ncConn != nil {
 		s.ncConn.Close()
-		s.ncConn = nil
+		s.ncConn, s.ncJS = nil, nil
 	}
 }
 
+// DrainNATS publishes pending messages and closes the NATS connection.
+func (s *Server) DrainNATS(ctx context.Context) error {
+	s.ncMu.Lock()
+	nc, js := s.ncConn, s.ncJS
+	s.ncConn, s.ncJS = nil, nil
+	s.ncMu.Unlock()
+
+	if nc == nil {
+		return nil
+	}
+	defer nc.Close()
+
+	// Wait for acknowledgements of JetStream messages
+	if js != nil {
+		defer js.close()
+		if err := js.wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := nc.Drain(); err != nil {
+		return err
+	}
+	return waitFor(ctx, nc.IsClosed)
+}
+
 // HandleNATSError handles NATS errors.
 func (s *Server) HandleNATSError(err error) {
 	// Do nothing if the server is shutting down or NATS is reconnecting
-	if s.IsShutdown() || s.IsNATSReconnecting() {
+	if s.IsShutdown() {
 		return
 	}
+	s.componentDown(ComponentNATS, err)
 
-	// Close NATS connection
-	s.Errorf("NATS: %v", err)
-	s.CloseNATS()
-
-	// Runs goroutine to restart NATS connection after RetryDelay
-	go func() {
-		cfg := s.NATSConfig()
-		s.ncReconn.Store(true)
-		s.Noticef("NATS: Reconnecting to %s in %ds", cfg.URL, cfg.RetryDelay)
+	// Close NATS connection and restart it with backoff
+	s.natsReconnect.Fail(err)
+}
 
-		select {
-		case <-s.quitCh:
-			return
-		case <-time.After(time.Duration(cfg.RetryDelay) * time.Second):
-			s.ncReconn.Store(false)
-			s.StartNATS()
-		}
-	}()
+// natsConnectionError returns whether the error is caused by the lost connection.
+func natsConnectionError(err error) bool {
+	return errors.Is(err, nats.ErrConnectionClosed) || errors.Is(err, nats.ErrDisconnected)
 }
 
-// NATSSend sends message to the NATS.
+// NATSSend sends message to the NATS, subjects of JetStream streams are published with acknowledgement.
 func (s *Server) NATSSend(object NATSSubjecter) {
 	s.ncMu.Lock()
-	nc := s.ncConn
+	nc, js := s.ncConn, s.ncJS
 	s.ncMu.Unlock()
 
-	if nc != nil {
-		if b, err := json.Marshal(object); err == nil {
-			if err := nc.Publish(object.NATSSubject(), b); err != nil {
-				s.HandleNATSError(err)
-			}
+	if nc == nil {
+		return
+	}
+	b, err := json.Marshal(object)
+	if err != nil {
+		return
+	}
+
+	if js != nil && js.cfg.jetStreamSubject(object.NATSSubject()) {
+		err = js.publish(object, b)
+		if err != nil {
+			s.metrics.JetStreamErrors.Inc("publish")
+		}
+	} else {
+		err = nc.Publish(object.NATSSubject(), b)
+		if err != nil {
+			s.metrics.NATSPublishErrors.Inc()
 		}
 	}
+
+	// Only the lost connection is restarted, the message is dropped on other errors
+	switch {
+	case err == nil:
+	case natsConnectionError(err):
+		s.HandleNATSError(err)
+	default:
+		s.Debugf("NATS: cannot publish %s: %v", object.NATSSubject(), err)
+	}
+}
+
+func init() {
+	Sinks["NATS"] = SinkFunc(func(s *Server, m Message) error {
+		s.NATSSend(m)
+		return nil
+	})
 }
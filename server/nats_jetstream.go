@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// JetStream stream Configuration, MaxAge and DuplicateWindow are in seconds.
+type JetStreamStreamConfig struct {
+	Name            string   `xml:"Name,attr"`
+	Subjects        []string `xml:"Subject"`
+	Retention       string   `xml:"Retention"`
+	Storage         string   `xml:"Storage"`
+	Replicas        int      `xml:"Replicas"`
+	MaxAge          int      `xml:"MaxAge"`
+	MaxBytes        int64    `xml:"MaxBytes"`
+	MaxMsgs         int64    `xml:"MaxMsgs"`
+	DuplicateWindow int      `xml:"DuplicateWindow"`
+}
+
+// JetStream Configuration, AckTimeout is in seconds (0 waits without limit), MaxPending limits messages waiting for acknowledgement.
+type JetStreamConfig struct {
+	Enabled    bool                    `xml:"Enabled"`
+	AckTimeout int                     `xml:"AckTimeout"`
+	MaxPending int                     `xml:"MaxPending"`
+	Streams    []JetStreamStreamConfig `xml:"Stream"`
+}
+
+// DefaultJetStreamConfig returns default JetStream config.
+func DefaultJetStreamConfig() JetStreamConfig {
+	return JetStreamConfig{
+		Enabled:    false,
+		AckTimeout: 5,
+		MaxPending: 4000,
+		Streams:    []JetStreamStreamConfig{},
+	}
+}
+
+// DefaultJetStreamStreams returns streams for candles and quotes used when streams are not configured.
+func DefaultJetStreamStreams() []JetStreamStreamConfig {
+	stream := func(name string, subject string) JetStreamStreamConfig {
+		return JetStreamStreamConfig{
+			Name:            name,
+			Subjects:        []string{subject},
+			Retention:       "limits",
+			Storage:         "file",
+			Replicas:        1,
+			MaxAge:          7 * 24 * 3600,
+			DuplicateWindow: 120,
+		}
+	}
+	return []JetStreamStreamConfig{stream("CANDLES", "C.>"), stream("QUOTES", "Q.>")}
+}
+
+// JetStreamStreams returns configured streams or default ones.
+func (c JetStreamConfig) JetStreamStreams() []JetStreamStreamConfig {
+	if len(c.Streams) > 0 {
+		return c.Streams
+	}
+	return DefaultJetStreamStreams()
+}
+
+// StreamConfig converts the configuration to the JetStream stream configuration.
+func (c JetStreamStreamConfig) StreamConfig() (*nats.StreamConfig, error) {
+	cfg := &nats.StreamConfig{
+		Name:       c.Name,
+		Subjects:   c.Subjects,
+		Replicas:   c.Replicas,
+		MaxAge:     time.Duration(c.MaxAge) * time.Second,
+		MaxBytes:   c.MaxBytes,
+		MaxMsgs:    c.MaxMsgs,
+		Duplicates: time.Duration(c.DuplicateWindow) * time.Second,
+	}
+
+	if c.Name == "" || len(c.Subjects) == 0 {
+		return nil, fmt.Errorf("stream requires Name and Subject")
+	}
+	if c.Retention != "" {
+		if err := cfg.Retention.UnmarshalJSON([]byte(strconv.Quote(c.Retention))); err != nil {
+			return nil, fmt.Errorf("stream %s: unknown retention '%s'", c.Name, c.Retention)
+		}
+	}
+	if c.Storage != "" {
+		if err := cfg.Storage.UnmarshalJSON([]byte(strconv.Quote(c.Storage))); err != nil {
+			return nil, fmt.Errorf("stream %s: unknown storage '%s'", c.Name, c.Storage)
+		}
+	}
+	return cfg, nil
+}
+
+// natsSubjectMatch returns whether the subject matches the pattern with * and > wildcards.
+func natsSubjectMatch(pattern string, subject string) bool {
+	p := strings.Split(pattern, ".")
+	t := strings.Split(subject, ".")
+
+	for i, token := range p {
+		if token == ">" {
+			return len(t) > i
+		}
+		if i >= len(t) || (token != "*" && token != t[i]) {
+			return false
+		}
+	}
+	return len(p) == len(t)
+}
+
+// jetStreamSubject returns whether the subject is stored by one of the streams.
+func (c JetStreamConfig) jetStreamSubject(subject string) bool {
+	for _, stream := range c.JetStreamStreams() {
+		for _, pattern := range stream.Subjects {
+			if natsSubjectMatch(pattern, subject) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NATSMsgID returns the de-duplication ID of the message derived from the subject and timestamps,
+// trades are identified by the trade ID or the price and quantity since several trades may share the timestamp.
+func NATSMsgID(object NATSSubjecter) string {
+	if t, ok := object.(*Trade); ok {
+		if t.TradeID != "" {
+			return fmt.Sprintf("%s.%s", object.NATSSubject(), t.TradeID)
+		}
+		return fmt.Sprintf("%s.%d.%s.%s.%s", object.NATSSubject(), t.Time, t.Price, t.Quantity, t.Side)
+	}
+	if m, ok := object.(Message); ok {
+		h := m.Header()
+		return fmt.Sprintf("%s.%d.%d", object.NATSSubject(), h.Time, h.TimeSrv)
+	}
+	return object.NATSSubject()
+}
+
+// jetStreamAck is the message waiting for acknowledgement.
+type jetStreamAck struct {
+	future   nats.PubAckFuture
+	deadline time.Time
+}
+
+// jetStreamPublisher publishes messages asynchronously, acknowledgements are checked by a separate goroutine.
+type jetStreamPublisher struct {
+	js      nats.JetStreamContext
+	cfg     JetStreamConfig
+	pending chan jetStreamAck
+	done    chan struct{}
+	once    sync.Once
+}
+
+// publish sends the message without waiting for acknowledgement, fails if too many messages are pending.
+func (p *jetStreamPublisher) publish(object NATSSubjecter, b []byte) error {
+	f, err := p.js.PublishAsync(object.NATSSubject(), b, nats.MsgId(NATSMsgID(object)))
+	if err != nil {
+		return err
+	}
+
+	ack := jetStreamAck{future: f}
+	if p.cfg.AckTimeout > 0 {
+		ack.deadline = time.Now().Add(time.Duration(p.cfg.AckTimeout) * time.Second)
+	}
+
+	select {
+	case p.pending <- ack:
+	case <-p.done:
+	}
+	return nil
+}
+
+// waitAck waits for the acknowledgement until the deadline, returns the failure reason and error.
+func waitAck(ack jetStreamAck, done <-chan struct{}) (string, error) {
+	// Received acknowledgements take precedence over the expired deadline
+	select {
+	case <-ack.future.Ok():
+		return "", nil
+	case err := <-ack.future.Err():
+		return "ack", err
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if !ack.deadline.IsZero() {
+		timer := time.NewTimer(time.Until(ack.deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-ack.future.Ok():
+		return "", nil
+	case err := <-ack.future.Err():
+		return "ack", err
+	case <-timeout:
+		return "timeout", fmt.Errorf("acknowledgement timeout")
+	case <-done:
+		return "", nil
+	}
+}
+
+// checkAcks counts failed and timed out acknowledgements until the publisher is closed.
+func (s *Server) checkAcks(p *jetStreamPublisher) {
+	for {
+		select {
+		case ack := <-p.pending:
+			if reason, err := waitAck(ack, p.done); err != nil {
+				s.metrics.JetStreamErrors.Inc(reason)
+				s.Debugf("NATS: JetStream %s: %v", ack.future.Msg().Subject, err)
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// wait waits until pending messages are acknowledged or the context is done.
+func (p *jetStreamPublisher) wait(ctx context.Context) error {
+	select {
+	case <-p.js.PublishAsyncComplete():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops checking acknowledgements.
+func (p *jetStreamPublisher) close() {
+	p.once.Do(func() { close(p.done) })
+}
+
+// SetupJetStream creates or updates the streams and starts the publisher.
+func (s *Server) SetupJetStream(nc *nats.Conn) (*jetStreamPublisher, error) {
+	cfg := s.NATSConfig().JetStream
+	maxPending := cfg.MaxPending
+	if maxPending < 1 {
+		maxPending = DefaultJetStreamConfig().MaxPending
+	}
+
+	js, err := nc.JetStream(nats.PublishAsyncMaxPending(maxPending))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stream := range cfg.JetStreamStreams() {
+		sc, err := stream.StreamConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = js.StreamInfo(sc.Name)
+		switch {
+		case errors.Is(err, nats.ErrStreamNotFound):
+			s.Noticef("NATS: Creating JetStream stream %s %v", sc.Name, sc.Subjects)
+			_, err = js.AddStream(sc)
+		case err == nil:
+			_, err = js.UpdateStream(sc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("JetStream stream %s: %v", sc.Name, err)
+		}
+	}
+
+	p := &jetStreamPublisher{js: js, cfg: cfg, pending: make(chan jetStreamAck, maxPending), done: make(chan struct{})}
+	go s.checkAcks(p)
+	return p, nil
+}
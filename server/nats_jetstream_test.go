@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestNATSSubjectMatch(t *testing.T) {
+	expectDeepEqual(t, natsSubjectMatch("C.>", "C.1m.BTCUSDT.Binance"), true)
+	expectDeepEqual(t, natsSubjectMatch("C.>", "C"), false)
+	expectDeepEqual(t, natsSubjectMatch("Q.*.Binance", "Q.BTCUSDT.Binance"), true)
+	expectDeepEqual(t, natsSubjectMatch("Q.*.Binance", "Q.BTCUSDT.Kraken"), false)
+	expectDeepEqual(t, natsSubjectMatch("Q.*", "Q.BTCUSDT.Binance"), false)
+
+	cfg := DefaultJetStreamConfig()
+	expectDeepEqual(t, cfg.jetStreamSubject("C.1m.BTCUSDT.Binance"), true)
+	expectDeepEqual(t, cfg.jetStreamSubject("T.BTCUSDT.Binance"), false)
+}
+
+func TestNATSMsgID(t *testing.T) {
+	c := &Candle{MessageHeader: MessageHeader{Symbol: "BTCUSDT", Source: "Binance", Time: 60000000, TimeSrv: 60500000}, Interval: "1m"}
+	expectDeepEqual(t, NATSMsgID(c), "C.1m.BTCUSDT.Binance.60000000.60500000")
+
+	// Trades with the same timestamp have different IDs
+	h := MessageHeader{Symbol: "BTCUSDT", Source: "Binance", Time: 60000000, TimeSrv: 60500000}
+	t1 := &Trade{MessageHeader: h, TradeID: "1", Price: "100", Quantity: "1"}
+	t2 := &Trade{MessageHeader: h, TradeID: "2", Price: "100", Quantity: "1"}
+	expectDeepEqual(t, NATSMsgID(t1), "T.BTCUSDT.Binance.1")
+	expectDeepEqual(t, NATSMsgID(t2), "T.BTCUSDT.Binance.2")
+
+	t1.TradeID, t2.TradeID, t2.Quantity = "", "", "2"
+	expectDeepEqual(t, NATSMsgID(t1) != NATSMsgID(t2), true)
+}
+
+func TestJetStreamStreamConfig(t *testing.T) {
+	cfg, err := DefaultJetStreamStreams()[0].StreamConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectDeepEqual(t, cfg, &nats.StreamConfig{
+		Name:       "CANDLES",
+		Subjects:   []string{"C.>"},
+		Retention:  nats.LimitsPolicy,
+		Storage:    nats.FileStorage,
+		Replicas:   1,
+		MaxAge:     7 * 24 * time.Hour,
+		Duplicates: 2 * time.Minute,
+	})
+
+	_, err = JetStreamStreamConfig{Name: "FOO", Subjects: []string{"F.>"}, Retention: "forever"}.StreamConfig()
+	expectDeepEqual(t, err.Error(), "stream FOO: unknown retention 'forever'")
+}
+
+type testPubAckFuture struct {
+	ok  chan *nats.PubAck
+	err chan error
+}
+
+func (f *testPubAckFuture) Ok() <-chan *nats.PubAck { return f.ok }
+func (f *testPubAckFuture) Err() <-chan error       { return f.err }
+func (f *testPubAckFuture) Msg() *nats.Msg          { return &nats.Msg{Subject: "C.1m.BTCUSDT.Binance"} }
+
+func TestWaitAck(t *testing.T) {
+	future := func() *testPubAckFuture {
+		return &testPubAckFuture{ok: make(chan *nats.PubAck, 1), err: make(chan error, 1)}
+	}
+	done := make(chan struct{})
+
+	// The received acknowledgement is not a timeout after the deadline
+	f := future()
+	f.ok <- &nats.PubAck{}
+	reason, err := waitAck(jetStreamAck{future: f, deadline: time.Now().Add(-time.Second)}, done)
+	expectDeepEqual(t, reason, "")
+	expectDeepEqual(t, err, nil)
+
+	f = future()
+	f.err <- nats.ErrNoStreamResponse
+	reason, err = waitAck(jetStreamAck{future: f}, done)
+	expectDeepEqual(t, reason, "ack")
+	expectDeepEqual(t, err, nats.ErrNoStreamResponse)
+
+	reason, _ = waitAck(jetStreamAck{future: future(), deadline: time.Now().Add(10 * time.Millisecond)}, done)
+	expectDeepEqual(t, reason, "timeout")
+}
+
+func TestNATSConnectionError(t *testing.T) {
+	expectDeepEqual(t, natsConnectionError(nats.ErrConnectionClosed), true)
+	expectDeepEqual(t, natsConnectionError(fmt.Errorf("publish: %w", nats.ErrDisconnected)), true)
+	expectDeepEqual(t, natsConnectionError(nats.ErrTimeout), false)
+	expectDeepEqual(t, natsConnectionError(nats.ErrMaxPayload), false)
+}
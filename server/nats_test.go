@@ -2,6 +2,9 @@ package server
 
 import (
 	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
 )
 
 func TestNATSConfig(t *testing.T) {
@@ -15,7 +18,49 @@ func TestCandleSubject(t *testing.T) {
 	expectDeepEqual(t, r.NATSSubject(), "C.1m.foo.bar")
 }
 
+func TestTradeSubject(t *testing.T) {
+	r := &Trade{MessageHeader: MessageHeader{Symbol: "foo", Source: "bar"}}
+	expectDeepEqual(t, r.NATSSubject(), "T.foo.bar")
+}
+
 func TestQuoteSubject(t *testing.T) {
 	r := &Quote{MessageHeader: MessageHeader{Symbol: "foo", Source: "bar"}}
 	expectDeepEqual(t, r.NATSSubject(), "Q.foo.bar")
 }
+
+func TestNATSOptions(t *testing.T) {
+	cfg := DefaultNATSConfig()
+	cfg.Servers = []string{"nats://10.0.0.2:4222", "nats://10.0.0.3:4222"}
+	cfg.User = "foo"
+	cfg.Password = "bar"
+	cfg.TLS = true
+	cfg.MaxReconnects = -1
+	expectDeepEqual(t, cfg.ServerURLs(), "nats://127.0.0.1:4222,nats://10.0.0.2:4222,nats://10.0.0.3:4222")
+
+	options, err := cfg.NATSOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := nats.GetDefaultOptions()
+	for _, option := range options {
+		option(&o)
+	}
+	expectDeepEqual(t, o.Name, "StockMQ")
+	expectDeepEqual(t, o.User, "foo")
+	expectDeepEqual(t, o.Password, "bar")
+	expectDeepEqual(t, o.Secure, true)
+	expectDeepEqual(t, o.MaxReconnect, -1)
+	expectDeepEqual(t, o.ReconnectWait, 2*time.Second)
+	expectDeepEqual(t, o.PingInterval, 120*time.Second)
+
+	cfg.NKeySeed = "/nonexistent.nk"
+	_, err = cfg.NATSOptions()
+	expectDeepEqual(t, err != nil, true)
+}
+
+func TestNATSValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NATS.User = "foo"
+	cfg.NATS.Token = "bar"
+	expectDeepEqual(t, cfg.Validate()[0].Error(), "NATS: User, Token, NKeySeed and Credentials are mutually exclusive")
+}
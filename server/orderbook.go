@@ -0,0 +1,146 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Order book Configuration.
+type OrderBookConfig struct {
+	Enabled bool   `xml:"Enabled"`
+	URL     string `xml:"URL"`
+	Limit   int    `xml:"Limit"`
+	Levels  int    `xml:"Levels"`
+	Timeout int    `xml:"Timeout"`
+}
+
+type orderBookLevel struct {
+	price    float64
+	text     string
+	quantity string
+}
+
+// orderBookSide keeps price levels sorted from the best price.
+type orderBookSide struct {
+	desc   bool
+	levels []orderBookLevel
+}
+
+// OrderBook represents the local L2 order book.
+type OrderBook struct {
+	Symbol string
+
+	bids orderBookSide
+	asks orderBookSide
+}
+
+// NewOrderBook returns an empty order book.
+func NewOrderBook(symbol string) *OrderBook {
+	b := &OrderBook{Symbol: symbol}
+	b.Reset()
+	return b
+}
+
+// Reset removes all price levels.
+func (b *OrderBook) Reset() {
+	b.bids = orderBookSide{desc: true}
+	b.asks = orderBookSide{}
+}
+
+// search returns the position of the price level or where it is inserted.
+func (side *orderBookSide) search(price float64) int {
+	return sort.Search(len(side.levels), func(i int) bool {
+		if side.desc {
+			return side.levels[i].price <= price
+		}
+		return side.levels[i].price >= price
+	})
+}
+
+// set updates the price level, zero quantity removes it.
+func (side *orderBookSide) set(level orderBookLevel, remove bool) {
+	i := side.search(level.price)
+	found := i < len(side.levels) && side.levels[i].price == level.price
+
+	switch {
+	case remove && found:
+		side.levels = append(side.levels[:i], side.levels[i+1:]...)
+	case remove:
+	case found:
+		side.levels[i] = level
+	default:
+		side.levels = append(side.levels, orderBookLevel{})
+		copy(side.levels[i+1:], side.levels[i:])
+		side.levels[i] = level
+	}
+}
+
+// apply updates the side of the book.
+func (side *orderBookSide) apply(levels [][]string) error {
+	for _, level := range levels {
+		if len(level) < 2 {
+			return fmt.Errorf("invalid price level %v", level)
+		}
+
+		price, err := strconv.ParseFloat(level[0], 64)
+		if err != nil {
+			return fmt.Errorf("invalid price '%s'", level[0])
+		}
+		quantity, err := strconv.ParseFloat(level[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid quantity '%s'", level[1])
+		}
+
+		side.set(orderBookLevel{price: price, text: level[0], quantity: level[1]}, quantity == 0)
+	}
+	return nil
+}
+
+// top returns up to n best price levels, all if n < 1.
+func (side *orderBookSide) top(n int) [][]string {
+	levels := side.levels
+	if n > 0 && len(levels) > n {
+		levels = levels[:n]
+	}
+
+	r := make([][]string, 0, len(levels))
+	for _, l := range levels {
+		r = append(r, []string{l.text, l.quantity})
+	}
+	return r
+}
+
+// Apply applies the updates to the book.
+func (b *OrderBook) Apply(bids [][]string, asks [][]string) error {
+	if err := b.bids.apply(bids); err != nil {
+		return err
+	}
+	return b.asks.apply(asks)
+}
+
+// Bids returns the best n bids, all bids if n < 1.
+func (b *OrderBook) Bids(n int) [][]string {
+	return b.bids.top(n)
+}
+
+// Asks returns the best n asks, all asks if n < 1.
+func (b *OrderBook) Asks(n int) [][]string {
+	return b.asks.top(n)
+}
+
+// Quote returns the Quote with the top n levels, full book if n < 1.
+func (b *OrderBook) Quote(n int) *Quote {
+	r := &Quote{
+		MessageHeader: MessageHeader{
+			Symbol: b.Symbol,
+		},
+
+		Bids: b.Bids(n),
+		Asks: b.Asks(n),
+	}
+
+	r.BidsDepth = len(r.Bids)
+	r.AsksDepth = len(r.Asks)
+	return r
+}
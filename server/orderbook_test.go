@@ -0,0 +1,30 @@
+package server
+
+import "testing"
+
+func TestOrderBookApply(t *testing.T) {
+	b := NewOrderBook("BTCUSDT")
+	if err := b.Apply([][]string{{"99.5", "1"}, {"100.0", "2"}, {"98", "3"}}, [][]string{{"101", "1"}, {"100.5", "4"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.Apply([][]string{{"98", "0.000"}}, [][]string{{"100.5", "5"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, b.Bids(0), [][]string{{"100.0", "2"}, {"99.5", "1"}})
+	expectDeepEqual(t, b.Asks(1), [][]string{{"100.5", "5"}})
+	expectDeepEqual(t, b.Quote(1), &Quote{
+		MessageHeader: MessageHeader{Symbol: "BTCUSDT"},
+		BidsDepth:     1,
+		Bids:          [][]string{{"100.0", "2"}},
+		AsksDepth:     1,
+		Asks:          [][]string{{"100.5", "5"}},
+	})
+
+	// Levels are matched by the price value and kept sorted
+	if err := b.Apply([][]string{{"100", "3"}, {"99.75", "1"}, {"99.5", "0"}}, [][]string{{"100.75", "1"}, {"102", "2"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectDeepEqual(t, b.Bids(0), [][]string{{"100", "3"}, {"99.75", "1"}})
+	expectDeepEqual(t, b.Asks(0), [][]string{{"100.5", "5"}, {"100.75", "1"}, {"101", "1"}, {"102", "2"}})
+}
@@ -1,17 +1,64 @@
 package server
 
+// Message is the message delivered to sinks.
+type Message interface {
+	NATSSubjecter
+	InfluxDBPointer
+
+	Header() *MessageHeader
+}
+
+// process queues the message to sinks configured for its source and publishes it to gRPC streams.
+func (s *Server) process(m Message) {
+	for _, q := range s.sinksFor(m.Header().Source) {
+		q.push(s, m)
+	}
+	s.publishMarketData(m)
+	s.observeMessage(m)
+}
+
 // ProcessCandle processes the candle.
 func (s *Server) ProcessCandle(c *Candle) error {
-	s.NATSSend(c)
-	s.MongoDBStore(c)
-	s.InfluxDBStore(c)
+	s.process(c)
+
+	// Publish higher timeframes closed by the candle
+	if s.aggregator != nil {
+		bars, err := s.aggregator.Add(c)
+		if err != nil {
+			s.Errorf("Aggregator: %s %s: %v", c.Symbol, c.Source, err)
+		}
+		for _, bar := range bars {
+			s.process(bar)
+		}
+	}
+	return nil
+}
+
+// ProcessTrade processes the trade.
+func (s *Server) ProcessTrade(c *Trade) error {
+	s.process(c)
+
+	s.mu.RLock()
+	conn := s.wsConnections[c.Source]
+	s.mu.RUnlock()
+
+	// Publish candles closed by the trade
+	if conn != nil && conn.candleBuilder != nil {
+		bars, err := conn.candleBuilder.Add(c)
+		if err != nil {
+			s.Errorf("CandleBuilder: %s %s: %v", c.Symbol, c.Source, err)
+		}
+		for _, bar := range bars {
+			if err := s.ProcessCandle(bar); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
 // ProcessQuote processes the quote.
 func (s *Server) ProcessQuote(c *Quote) error {
-	s.NATSSend(c)
-	s.MongoDBStore(c)
-	s.InfluxDBStore(c)
+	s.process(c)
 	return nil
 }
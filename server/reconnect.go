@@ -0,0 +1,274 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default time in seconds the connection stays up before attempts are reset.
+const defaultStableAfter = 60
+
+// Reconnect Configuration, the initial delay is RetryDelay of the component.
+type ReconnectConfig struct {
+	MaxDelay    int     `xml:"MaxDelay"`
+	Multiplier  float64 `xml:"Multiplier"`
+	Jitter      float64 `xml:"Jitter"`
+	MaxAttempts int     `xml:"MaxAttempts"`
+	StableAfter int     `xml:"StableAfter"`
+}
+
+// DefaultReconnectConfig returns default reconnect config.
+func DefaultReconnectConfig() ReconnectConfig {
+	return ReconnectConfig{
+		MaxDelay:    60,
+		Multiplier:  2,
+		Jitter:      0.2,
+		MaxAttempts: 0,
+		StableAfter: defaultStableAfter,
+	}
+}
+
+// ReconnectConfig returns reconnect configuration.
+func (s *Server) ReconnectConfig() ReconnectConfig {
+	return s.ServerConfig().Reconnect
+}
+
+// Backoff returns the delay before the attempt, jitter is applied by the caller.
+func (c ReconnectConfig) Backoff(retryDelay int, attempt int) time.Duration {
+	d := float64(retryDelay) * math.Pow(math.Max(c.Multiplier, 1), float64(attempt))
+	if c.MaxDelay > 0 && d > float64(c.MaxDelay) {
+		d = float64(c.MaxDelay)
+	}
+	return time.Duration(d * float64(time.Second))
+}
+
+// StableWindow returns the time the connection stays up before attempts are reset, 0 means the default.
+func (c ReconnectConfig) StableWindow() time.Duration {
+	if c.StableAfter < 1 {
+		return defaultStableAfter * time.Second
+	}
+	return time.Duration(c.StableAfter) * time.Second
+}
+
+// withJitter randomizes the delay by +/- jitter fraction.
+func withJitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + jitter*(2*rand.Float64()-1)))
+}
+
+// Reconnector states.
+const (
+	ReconnectStateActive  = "active"
+	ReconnectStateWaiting = "waiting"
+	ReconnectStatePaused  = "paused"
+	ReconnectStateFailed  = "failed"
+)
+
+// ReconnectStatus represents the state of the reconnect loop.
+type ReconnectStatus struct {
+	State       string     `json:"state"`
+	Attempts    int        `json:"attempts"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+}
+
+// Reconnector schedules reconnects of the component with exponential backoff.
+type Reconnector struct {
+	s         *Server
+	component string
+	prefix    string
+
+	// Returns RetryDelay of the component
+	retryDelay func() int
+
+	// Closes and starts the connection
+	disconnect func()
+	connect    func()
+
+	mu          sync.Mutex
+	state       string
+	attempts    int
+	generation  int
+	connectedAt time.Time
+	nextAttempt time.Time
+}
+
+// newReconnector returns the reconnector of the component, prefix is used in logs.
+func (s *Server) newReconnector(component string, prefix string, retryDelay func() int, disconnect func(), connect func()) *Reconnector {
+	return &Reconnector{
+		s:          s,
+		component:  component,
+		prefix:     prefix,
+		retryDelay: retryDelay,
+		disconnect: disconnect,
+		connect:    connect,
+		state:      ReconnectStateActive,
+	}
+}
+
+// Status returns the state of the reconnect loop.
+func (r *Reconnector) Status() ReconnectStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := ReconnectStatus{State: r.state, Attempts: r.attempts}
+	if r.state == ReconnectStateWaiting && !r.nextAttempt.IsZero() {
+		t := r.nextAttempt
+		status.NextAttempt = &t
+	}
+	return status
+}
+
+// IsWaiting returns whether the reconnect is scheduled.
+func (r *Reconnector) IsWaiting() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state == ReconnectStateWaiting
+}
+
+// IsPaused returns whether the reconnects are paused.
+func (r *Reconnector) IsPaused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state == ReconnectStatePaused
+}
+
+// Connected records the successful connect, attempts are reset once the connection is stable.
+func (r *Reconnector) Connected() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectedAt = time.Now()
+}
+
+// Fail schedules the reconnect, returns false if it is already scheduled, paused or failed.
+func (r *Reconnector) Fail(err error) bool {
+	r.mu.Lock()
+	if r.state != ReconnectStateActive {
+		r.mu.Unlock()
+		return false
+	}
+	r.state = ReconnectStateWaiting
+	r.generation++
+	r.nextAttempt = time.Time{}
+	generation := r.generation
+	connectedAt := r.connectedAt
+	r.connectedAt = time.Time{}
+	r.mu.Unlock()
+
+	r.s.Errorf("%s: %v", r.prefix, err)
+	r.disconnect()
+
+	// Config is read outside of the caller as it may hold the server lock
+	go func() {
+		cfg := r.s.ReconnectConfig()
+		retryDelay := r.retryDelay()
+
+		r.mu.Lock()
+		// The connection was stable
+		if !connectedAt.IsZero() && time.Since(connectedAt) >= cfg.StableWindow() {
+			r.attempts = 0
+		}
+		if cfg.MaxAttempts > 0 && r.attempts >= cfg.MaxAttempts {
+			r.state = ReconnectStateFailed
+			r.mu.Unlock()
+			r.s.Errorf("%s: Giving up after %d attempts", r.prefix, cfg.MaxAttempts)
+			r.s.componentDown(r.component, fmt.Errorf("giving up after %d attempts", cfg.MaxAttempts))
+			return
+		}
+		delay := withJitter(cfg.Backoff(retryDelay, r.attempts), cfg.Jitter)
+		r.attempts++
+		r.nextAttempt = time.Now().Add(delay)
+		attempt := r.attempts
+		r.mu.Unlock()
+
+		r.s.Noticef("%s: Reconnecting in %v (attempt %d)", r.prefix, delay.Truncate(time.Millisecond), attempt)
+
+		select {
+		case <-r.s.quitCh:
+			return
+		case <-time.After(delay):
+			// Paused component is started by Resume
+			r.mu.Lock()
+			start := r.state == ReconnectStateWaiting && r.generation == generation
+			if start {
+				r.state = ReconnectStateActive
+			}
+			r.mu.Unlock()
+
+			if start {
+				r.connect()
+			}
+		}
+	}()
+	return true
+}
+
+// Reset clears attempts and closes the circuit, returns false if paused.
+func (r *Reconnector) Reset() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state == ReconnectStatePaused {
+		return false
+	}
+	if r.state == ReconnectStateFailed {
+		r.state = ReconnectStateActive
+	}
+	r.attempts = 0
+	return true
+}
+
+// Pause stops reconnects, returns false if already paused.
+func (r *Reconnector) Pause() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state == ReconnectStatePaused {
+		return false
+	}
+	r.state = ReconnectStatePaused
+	r.attempts = 0
+	return true
+}
+
+// Resume allows reconnects, returns true if the caller should start the connection.
+func (r *Reconnector) Resume() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.state != ReconnectStatePaused {
+		return false
+	}
+	r.state = ReconnectStateActive
+	return true
+}
+
+// ReconnectComponent closes the client and schedules reconnect, attempts are reset and the open circuit is closed.
+func (s *Server) ReconnectComponent(component string) error {
+	if !s.IsRunning() || s.IsShutdown() {
+		return fmt.Errorf("server is not running")
+	}
+
+	switch {
+	case component == ComponentNATS:
+		s.natsReconnect.Reset()
+		s.HandleNATSError(fmt.Errorf("reconnect requested"))
+	case component == ComponentMongoDB && s.MongoDBConfig().Enabled:
+		s.mongoReconnect.Reset()
+		s.HandleMongoDBError(fmt.Errorf("reconnect requested"))
+	case strings.HasPrefix(component, wsComponent("")):
+		conn := s.WSConnection(strings.TrimPrefix(component, wsComponent("")))
+		if conn == nil {
+			return fmt.Errorf("%w '%s'", ErrUnknownComponent, component)
+		}
+		return s.WSReconnect(conn)
+	default:
+		return fmt.Errorf("%w '%s'", ErrUnknownComponent, component)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReconnectBackoff(t *testing.T) {
+	cfg := DefaultReconnectConfig()
+	expectDeepEqual(t, cfg.Backoff(5, 0), 5*time.Second)
+	expectDeepEqual(t, cfg.Backoff(5, 2), 20*time.Second)
+	expectDeepEqual(t, cfg.Backoff(5, 10), 60*time.Second)
+
+	for i := 0; i < 100; i++ {
+		d := withJitter(10*time.Second, cfg.Jitter)
+		expectDeepEqual(t, d >= 8*time.Second && d <= 12*time.Second, true)
+	}
+
+	expectDeepEqual(t, cfg.StableWindow(), 60*time.Second)
+	expectDeepEqual(t, ReconnectConfig{}.StableWindow(), 60*time.Second)
+}
+
+func TestReconnectCircuit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Reconnect.MaxAttempts = 3
+	cfg.Reconnect.MaxDelay = 0
+	srv, _ := NewServer(cfg)
+	defer close(srv.quitCh)
+
+	// Every connection drops immediately, attempts are not reset without the delay limit
+	connects := make(chan struct{}, 10)
+	var r *Reconnector
+	r = srv.newReconnector("foo", "Foo", func() int { return 0 }, func() {}, func() {
+		connects <- struct{}{}
+		r.Connected()
+		r.Fail(fmt.Errorf("refused"))
+	})
+
+	expectDeepEqual(t, r.Fail(fmt.Errorf("refused")), true)
+	expectDeepEqual(t, r.Fail(fmt.Errorf("refused")), false)
+
+	for i := 0; i < 3; i++ {
+		<-connects
+	}
+	for r.Status().State != ReconnectStateFailed {
+		time.Sleep(time.Millisecond)
+	}
+	expectDeepEqual(t, r.Status(), ReconnectStatus{State: ReconnectStateFailed, Attempts: 3})
+
+	// Reset closes the circuit
+	expectDeepEqual(t, r.Reset(), true)
+	expectDeepEqual(t, r.Status(), ReconnectStatus{State: ReconnectStateActive})
+}
+
+func TestReconnectPause(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+	defer close(srv.quitCh)
+
+	r := srv.newReconnector("foo", "Foo", func() int { return 0 }, func() {}, func() {})
+	expectDeepEqual(t, r.Pause(), true)
+	expectDeepEqual(t, r.Pause(), false)
+	expectDeepEqual(t, r.Fail(fmt.Errorf("closed")), false)
+	expectDeepEqual(t, r.Reset(), false)
+	expectDeepEqual(t, r.Resume(), true)
+	expectDeepEqual(t, r.Resume(), false)
+}
+
+func TestReconnectComponent(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+	defer close(srv.quitCh)
+
+	expectDeepEqual(t, srv.ReconnectComponent(ComponentNATS) != nil, true)
+	srv.running.Store(true)
+
+	// The circuit is closed and the reconnect is scheduled
+	srv.natsReconnect.state = ReconnectStateFailed
+	srv.natsReconnect.attempts = 3
+	expectDeepEqual(t, srv.ReconnectComponent(ComponentNATS), nil)
+	for srv.natsReconnect.Status().Attempts != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	expectDeepEqual(t, srv.natsReconnect.Status().State, ReconnectStateWaiting)
+
+	expectDeepEqual(t, errors.Is(srv.ReconnectComponent(ComponentMongoDB), ErrUnknownComponent), true)
+	expectDeepEqual(t, errors.Is(srv.ReconnectComponent(wsComponent("foo")), ErrUnknownComponent), true)
+}
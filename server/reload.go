@@ -0,0 +1,143 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SetConfigLoader sets the function used to read the configuration on reload.
+func (s *Server) SetConfigLoader(loader func() (ServerConfig, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configLoader = loader
+}
+
+// Reload reads the configuration and applies the changes.
+func (s *Server) Reload() error {
+	s.mu.RLock()
+	loader := s.configLoader
+	s.mu.RUnlock()
+
+	if loader == nil {
+		return fmt.Errorf("configuration file is not set")
+	}
+
+	cfg, err := loader()
+	if err != nil {
+		return err
+	}
+	if errs := cfg.Validate(); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return s.ApplyConfig(cfg)
+}
+
+// enabledWebSockets returns enabled WebSocket configurations by name.
+func enabledWebSockets(cfg ServerConfig) map[string]WSConfig {
+	r := map[string]WSConfig{}
+	for _, ws := range cfg.WebSocket {
+		if ws.Enabled {
+			r[ws.Name] = ws
+		}
+	}
+	return r
+}
+
+// restartRequired returns sections which are applied only on start.
+func restartRequired(old ServerConfig, cfg ServerConfig) []string {
+	sections := map[string][2]interface{}{
+		"Monitor":    {old.Monitor, cfg.Monitor},
+		"MongoDB":    {old.MongoDB, cfg.MongoDB},
+		"InfluxDB":   {old.InfluxDB, cfg.InfluxDB},
+		"NATS":       {old.NATS, cfg.NATS},
+		"GRPC":       {old.GRPC, cfg.GRPC},
+		"Aggregator": {old.Aggregator, cfg.Aggregator},
+		"Sink":       {old.Sinks, cfg.Sinks},
+		"SinkQueue":  {old.SinkQueues, cfg.SinkQueues},
+	}
+
+	r := []string{}
+	for _, name := range sortedKeys(sections) {
+		if !reflect.DeepEqual(sections[name][0], sections[name][1]) {
+			r = append(r, name)
+		}
+	}
+	return r
+}
+
+// ApplyConfig applies Logger, Reconnect and WebSocket changes, other changes require restart.
+func (s *Server) ApplyConfig(cfg ServerConfig) error {
+	old := s.ServerConfig()
+	oldWS := enabledWebSockets(old)
+	newWS := enabledWebSockets(cfg)
+
+	// Connections are created before the changes to fail without side effects
+	started := map[string]*WSConnection{}
+	for _, name := range sortedKeys(newWS) {
+		if prev, ok := oldWS[name]; ok && reflect.DeepEqual(prev, newWS[name]) {
+			continue
+		}
+		conn, err := s.newWSConnection(newWS[name])
+		if err != nil {
+			return fmt.Errorf("WebSocket %s: %v", name, err)
+		}
+		started[name] = conn
+	}
+
+	for _, name := range restartRequired(old, cfg) {
+		s.Warnf("Reload: %s changed, restart required", name)
+	}
+
+	s.mu.Lock()
+	s.config.Logger = cfg.Logger
+	s.config.Reconnect = cfg.Reconnect
+	s.config.WebSocket = cfg.WebSocket
+
+	// Stop removed, disabled and changed connections
+	stopped := []*WSConnection{}
+	for _, name := range sortedKeys(oldWS) {
+		if _, ok := newWS[name]; ok && started[name] == nil {
+			continue
+		}
+		if conn := s.wsConnections[name]; conn != nil {
+			stopped = append(stopped, conn)
+			delete(s.wsConnections, name)
+		}
+	}
+	for name, conn := range started {
+		s.wsConnections[name] = conn
+	}
+	s.mu.Unlock()
+
+	for _, conn := range stopped {
+		name := conn.wsConfig.Name
+		if next := started[name]; next != nil {
+			s.Noticef("Reload: WebSocket %s changed, restarting", name)
+
+			// Runtime subscriptions are kept by the new connection
+			for _, stream := range conn.Subscriptions() {
+				next.subscriptions[stream] = struct{}{}
+			}
+		} else {
+			s.Noticef("Reload: WebSocket %s removed", name)
+			s.health.remove(wsComponent(name))
+		}
+
+		conn.reconnect.Pause()
+		conn.WSClose()
+	}
+
+	for _, name := range sortedKeys(started) {
+		if _, ok := oldWS[name]; !ok {
+			s.Noticef("Reload: WebSocket %s added", name)
+		}
+		s.componentDown(wsComponent(name), nil)
+		if s.IsRunning() && !s.IsShutdown() {
+			go s.StartWS(started[name])
+		}
+	}
+
+	s.Noticef("Configuration reloaded")
+	return nil
+}
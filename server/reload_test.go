@@ -0,0 +1,49 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WebSocket = []WSConfig{
+		{Name: "foo", Enabled: true, Handler: "Debug"},
+		{Name: "bar", Enabled: true, Handler: "Debug"},
+		{Name: "baz", Enabled: true, Handler: "Debug"},
+	}
+	srv, _ := NewServer(cfg)
+	foo := srv.WSConnection("foo")
+	bar := srv.WSConnection("bar")
+	bar.subscriptions["btcusdt@trade"] = struct{}{}
+
+	reload := DefaultConfig()
+	reload.Logger.Debug = true
+	reload.NATS.URL = "nats://nats:4222"
+	reload.WebSocket = []WSConfig{
+		{Name: "foo", Enabled: true, Handler: "Debug"},
+		{Name: "bar", Enabled: true, Handler: "Debug", RetryDelay: 1},
+		{Name: "baz", Enabled: false, Handler: "Debug"},
+		{Name: "qux", Enabled: true, Handler: "Debug"},
+	}
+	if err := srv.ApplyConfig(reload); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged connections are kept, changed are replaced with subscriptions
+	expectDeepEqual(t, srv.WSConnection("foo") == foo, true)
+	expectDeepEqual(t, srv.WSConnection("bar") == bar, false)
+	expectDeepEqual(t, srv.WSConnection("bar").Subscriptions(), []string{"btcusdt@trade"})
+	expectDeepEqual(t, bar.IsWSPaused(), true)
+	expectDeepEqual(t, srv.WSConnection("baz"), (*WSConnection)(nil))
+	expectDeepEqual(t, srv.WSConnection("qux") != nil, true)
+
+	// Sections applied on start are kept
+	expectDeepEqual(t, srv.LoggerConfig().Debug, true)
+	expectDeepEqual(t, srv.NATSConfig().URL, DefaultNATSConfig().URL)
+	expectDeepEqual(t, restartRequired(cfg, reload), []string{"NATS"})
+
+	// Invalid configuration is not applied
+	reload.WebSocket = []WSConfig{{Name: "foo", Enabled: true, Handler: "Debug", Sinks: []string{"Unknown"}}}
+	expectDeepEqual(t, srv.ApplyConfig(reload) != nil, true)
+	expectDeepEqual(t, srv.WSConnection("qux") != nil, true)
+}
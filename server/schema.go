@@ -15,6 +15,11 @@ type MessageHeader struct {
 	TimeRcv int64  `json:"time_rcv"`
 }
 
+// Header returns the common fields of the message.
+func (h *MessageHeader) Header() *MessageHeader {
+	return h
+}
+
 // Candle represents OLHCV bar.
 type Candle struct {
 	MessageHeader
@@ -25,6 +30,24 @@ type Candle struct {
 	Low      string `json:"low"`
 	Close    string `json:"close"`
 	Volume   string `json:"volume"`
+	Final    bool   `json:"final"`
+}
+
+// Trade side is the side of the taker.
+const (
+	TradeSideBuy  = "buy"
+	TradeSideSell = "sell"
+)
+
+// Trade represents a single trade.
+type Trade struct {
+	MessageHeader
+
+	TradeID    string `json:"trade_id"`
+	Price      string `json:"price"`
+	Quantity   string `json:"quantity"`
+	Side       string `json:"side"`
+	BuyerMaker bool   `json:"buyer_maker"`
 }
 
 // Quote represents bid and ask
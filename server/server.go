@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"sync"
 	"sync/atomic"
-	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/nats-io/nats.go"
@@ -22,29 +21,38 @@ import (
 )
 
 var (
-	ErrServerShutdown = errors.New("server was shutdown or already started")
+	ErrServerShutdown   = errors.New("server was shutdown or already started")
+	ErrUnknownComponent = errors.New("unknown component")
 )
 
 // Server Configuration.
 type ServerConfig struct {
-	Logger    LoggerConfig   `xml:"Logger"`
-	Monitor   MonitorConfig  `xml:"Monitor"`
-	MongoDB   MongoDBConfig  `xml:"MongoDB"`
-	InfluxDB  InfluxDBConfig `xml:"InfluxDB"`
-	NATS      NATSConfig     `xml:"NATS"`
-	GRPC      GRPCConfig     `xml:"GRPC"`
-	WebSocket []WSConfig     `xml:"WebSocket"`
+	Logger     LoggerConfig      `xml:"Logger"`
+	Monitor    MonitorConfig     `xml:"Monitor"`
+	MongoDB    MongoDBConfig     `xml:"MongoDB"`
+	InfluxDB   InfluxDBConfig    `xml:"InfluxDB"`
+	NATS       NATSConfig        `xml:"NATS"`
+	GRPC       GRPCConfig        `xml:"GRPC"`
+	Aggregator AggregatorConfig  `xml:"Aggregator"`
+	Reconnect  ReconnectConfig   `xml:"Reconnect"`
+	Shutdown   ShutdownConfig    `xml:"Shutdown"`
+	Sinks      []string          `xml:"Sink"`
+	SinkQueues []SinkQueueConfig `xml:"SinkQueue"`
+	WebSocket  []WSConfig        `xml:"WebSocket"`
 }
 
 // DefaultConfig returns default ServerConfig.
 func DefaultConfig() ServerConfig {
 	return ServerConfig{
-		Logger:   DefaultLoggerConfig(),
-		Monitor:  DefaultMonitorConfig(),
-		MongoDB:  DefaultMongoDBConfig(),
-		InfluxDB: DefaultInfluxDBConfig(),
-		NATS:     DefaultNATSConfig(),
-		GRPC:     DefaultGRPCConfig(),
+		Logger:     DefaultLoggerConfig(),
+		Monitor:    DefaultMonitorConfig(),
+		MongoDB:    DefaultMongoDBConfig(),
+		InfluxDB:   DefaultInfluxDBConfig(),
+		NATS:       DefaultNATSConfig(),
+		GRPC:       DefaultGRPCConfig(),
+		Aggregator: DefaultAggregatorConfig(),
+		Reconnect:  DefaultReconnectConfig(),
+		Shutdown:   DefaultShutdownConfig(),
 	}
 }
 
@@ -53,27 +61,70 @@ type WSConnection struct {
 
 	wsConfig WSConfig
 	wsConn   *websocket.Conn
-	wsReconn atomic.Bool
+
+	// Reconnects with backoff, pauses the connection
+	reconnect *Reconnector
+
+	// Time of the last received message in microseconds
+	wsLastMessage atomic.Int64
+
+	// Time of the last data message in microseconds, total and by symbol
+	wsLastData     atomic.Int64
+	symbolMu       sync.Mutex
+	symbolLastData map[string]int64
+
+	// Writes of init and subscription messages
+	wsWriteMu sync.Mutex
+
+	// Subscriptions added at runtime, restored after reconnect
+	subMu         sync.Mutex
+	subscriptions map[string]struct{}
+	requestID     atomic.Int64
+
+	// Local order books (Binance)
+	depthMu      sync.Mutex
+	depthBooks   map[string]*BinanceDepthBook
+	depthFetches sync.WaitGroup
+
+	// Candles built from trades
+	candleBuilder *CandleBuilder
+
+	// Sinks for the messages from this connection
+	sinks []*SinkQueue
 }
 
 type Server struct {
 	config           ServerConfig
+	configLoader     func() (ServerConfig, error)
 	quitCh           chan struct{}
+	ctx              context.Context
+	cancel           context.CancelCauseFunc
 	startupComplete  chan struct{}
 	shutdownComplete chan struct{}
+	shutdownReport   []ShutdownPhase
 
 	mu sync.RWMutex
 
 	running  atomic.Bool
 	shutdown atomic.Bool
 
+	// WebSocket readers processing messages
+	wsReaders atomic.Int64
+
 	// Monitor
 	monitorServer *http.Server
 
 	// MongoDB
-	mongoMu     sync.RWMutex
-	mongoReconn atomic.Bool
-	mongoClient *mongo.Client
+	mongoMu        sync.RWMutex
+	mongoReconnect *Reconnector
+	mongoClient    *mongo.Client
+
+	mongoBatchMu sync.Mutex
+	mongoBatches map[string][]mongoWrite
+	mongoFlushMu map[string]*sync.Mutex
+
+	// Writes the batch, replaced by tests
+	mongoBulkWrite func(ctx context.Context, collection *mongo.Collection, models []mongo.WriteModel) error
 
 	// InfluxDB
 	dbClient influxdb2.Client
@@ -83,10 +134,30 @@ type Server struct {
 	grpcListener net.Listener
 	grpcServer   *grpc.Server
 
+	// GRPC market data streams
+	streamMu sync.RWMutex
+	streams  map[*marketDataStream]struct{}
+
 	// NATS
-	ncMu     sync.RWMutex
-	ncConn   *nats.Conn
-	ncReconn atomic.Bool
+	ncMu          sync.RWMutex
+	ncConn        *nats.Conn
+	ncJS          *jetStreamPublisher
+	natsReconnect *Reconnector
+
+	// Candle aggregation
+	aggregator *CandleAggregator
+
+	// Prometheus metrics
+	metrics *Metrics
+
+	// Component states for readiness
+	health healthRegistry
+
+	// Sink queues
+	sinkMu       sync.Mutex
+	sinkQueues   map[string]*SinkQueue
+	sinksStarted bool
+	sinks        []*SinkQueue
 
 	// WebSocket connections.
 	wsConnections map[string]*WSConnection
@@ -97,15 +168,60 @@ func NewServer(config ServerConfig) (*Server, error) {
 	s := &Server{}
 	s.config = config
 	s.quitCh = make(chan struct{})
+	s.ctx, s.cancel = context.WithCancelCause(context.Background())
 	s.startupComplete = make(chan struct{})
 	s.shutdownComplete = make(chan struct{})
 	s.wsConnections = make(map[string]*WSConnection)
+	s.sinkQueues = make(map[string]*SinkQueue)
+	s.streams = make(map[*marketDataStream]struct{})
+	s.metrics = NewMetrics()
+
+	// Lookup default sinks
+	sinks := s.config.Sinks
+	if len(sinks) == 0 {
+		sinks = DefaultSinks
+	}
+	defaultSinks, err := s.lookupSinks(sinks)
+	if err != nil {
+		return nil, err
+	}
+	s.sinks = defaultSinks
 
 	// Create list of connections
 	for _, cfg := range s.config.WebSocket {
 		if cfg.Enabled {
-			s.wsConnections[cfg.Name] = &WSConnection{wsConfig: cfg}
+			conn, err := s.newWSConnection(cfg)
+			if err != nil {
+				return nil, err
+			}
+
+			s.wsConnections[cfg.Name] = conn
+			s.componentDown(wsComponent(cfg.Name), nil)
+		}
+	}
+
+	// Reconnect loops of the clients
+	s.natsReconnect = s.newReconnector(ComponentNATS, "NATS",
+		func() int { return s.NATSConfig().RetryDelay }, s.CloseNATS, s.StartNATS)
+	s.mongoReconnect = s.newReconnector(ComponentMongoDB, "MongoDB",
+		func() int { return s.MongoDBConfig().RetryDelay }, s.CloseMongoDB, s.StartMongoDB)
+
+	// Components are down until connected
+	s.componentDown(ComponentNATS, nil)
+	if s.config.MongoDB.Enabled {
+		s.componentDown(ComponentMongoDB, nil)
+	}
+	if s.config.InfluxDB.Enabled {
+		s.componentDown(ComponentInfluxDB, nil)
+	}
+
+	// Create candle aggregator
+	if s.config.Aggregator.Enabled {
+		aggregator, err := NewCandleAggregator(s.config.Aggregator.Intervals)
+		if err != nil {
+			return nil, err
 		}
+		s.aggregator = aggregator
 	}
 	return s, nil
 }
@@ -131,12 +247,19 @@ func (s *Server) Start() error {
 	// Start signal handler
 	s.HandleSignals()
 
+	// Start sink workers
+	s.startSinks()
+
 	// Start monitor
-	s.StartMonitor()
+	if err := s.StartMonitor(); err != nil {
+		s.Fatal(err)
+		return err
+	}
 
 	// Start MongoDB client
 	if s.MongoDBConfig().Enabled {
-		s.StartMongoDB()
+		go s.StartMongoDB()
+		s.MongoDBFlusher()
 	}
 
 	// Start InfluxDB client
@@ -146,6 +269,7 @@ func (s *Server) Start() error {
 
 	// Start GRPC
 	if err := s.StartGRPC(); err != nil {
+		s.Fatal(err)
 		return err
 	}
 
@@ -153,7 +277,7 @@ func (s *Server) Start() error {
 	s.StartNATS()
 
 	// Start WebSockets
-	for _, conn := range s.wsConnections {
+	for _, conn := range s.WSConnections() {
 		go s.StartWS(conn)
 	}
 
@@ -163,78 +287,30 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Shutdown will shutdown the server instance.
-func (s *Server) Shutdown() {
-	// Prevent multiple Shutdown() calls
+// Fatal shuts down the server, the error is reported by WaitForShutdown.
+func (s *Server) Fatal(err error) {
 	if s.IsShutdown() {
 		return
 	}
 
-	// Set shutdown to true to avoid race between multiple Shutdown() calls
-	s.shutdown.Store(true)
-
-	// Kick NATS if its running
-	s.Noticef("Shutting down the NATS connection...")
-	s.CloseNATS()
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Kick WebSocket if its running
-	for k, conn := range s.wsConnections {
-		conn.Lock()
-		if conn.wsConn != nil {
-			s.Noticef("Shutting down the %s websocket...", k)
-			conn.wsConn.Close()
-			conn.wsConn = nil
-		}
-		conn.Unlock()
-	}
-
-	// Kick off HTTP monitor
-	if s.monitorServer != nil {
-		s.Noticef("Shutting down the monitor...")
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-		defer cancel()
-		if err := s.monitorServer.Shutdown(ctx); err != nil {
-			s.Errorf("error during graceful shutdown: %v", err)
-		}
-		s.monitorServer = nil
-	}
-
-	// Kick off MongoDB
-	if s.mongoClient != nil {
-		s.Noticef("Shutting down the MongoDB connection...")
-		s.CloseMongoDB()
-	}
-
-	// Kick off InfluxDB
-	if s.dbWriter != nil {
-		s.Noticef("Shutting down the InfluxDB connection...")
-		s.dbWriter.Flush()
-
-		if s.dbClient != nil {
-			s.dbClient.Close()
-		}
-	}
-
-	// Kick off GRPC server
-	if s.grpcListener != nil {
-		s.Noticef("Shutting down the GRPC server...")
-		s.grpcServer.Stop()
-		s.grpcListener.Close()
-	}
-
-	// Release go routines
-	close(s.quitCh)
+	s.Errorf("%v (FATAL)", err)
+	s.cancel(err)
+	s.Shutdown()
+}
 
-	// Notify the shutdown is complete
-	close(s.shutdownComplete)
+// Context returns the context cancelled on shutdown.
+func (s *Server) Context() context.Context {
+	return s.ctx
 }
 
-// WaitForShutdown will block until the server has been fully shutdown.
-func (s *Server) WaitForShutdown() {
+// WaitForShutdown will block until the server has been fully shutdown, returns the fatal error if any.
+func (s *Server) WaitForShutdown() error {
 	<-s.shutdownComplete
+
+	if err := context.Cause(s.ctx); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
 }
 
 // IsRunning returns whether service is running.
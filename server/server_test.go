@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"net"
 	"testing"
 )
 
@@ -8,3 +10,29 @@ func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	expectDeepEqual(t, cfg, cfg)
 }
+
+func TestStartBindError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	cfg := DefaultConfig()
+	cfg.Monitor.Bind = "127.0.0.1:0"
+	cfg.GRPC.Bind = l.Addr().String()
+	srv, _ := NewServer(cfg)
+
+	// Started components are closed, the error is reported as the shutdown cause
+	err = srv.Start()
+	expectDeepEqual(t, err != nil, true)
+	expectDeepEqual(t, srv.IsShutdown(), true)
+	expectDeepEqual(t, srv.WaitForShutdown(), err)
+	expectDeepEqual(t, srv.Context().Err(), context.Canceled)
+}
+
+func TestShutdownCause(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+	srv.Shutdown()
+	expectDeepEqual(t, srv.WaitForShutdown(), nil)
+}
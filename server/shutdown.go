@@ -0,0 +1,237 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Shutdown Configuration, timeouts are in seconds, 0 waits without limit.
+type ShutdownConfig struct {
+	IngestionTimeout int `xml:"IngestionTimeout"`
+	DrainTimeout     int `xml:"DrainTimeout"`
+	SinksTimeout     int `xml:"SinksTimeout"`
+	GRPCTimeout      int `xml:"GRPCTimeout"`
+	MonitorTimeout   int `xml:"MonitorTimeout"`
+}
+
+// DefaultShutdownConfig returns default Shutdown config.
+func DefaultShutdownConfig() ShutdownConfig {
+	return ShutdownConfig{
+		IngestionTimeout: 5,
+		DrainTimeout:     10,
+		SinksTimeout:     10,
+		GRPCTimeout:      5,
+		MonitorTimeout:   1,
+	}
+}
+
+// ShutdownConfig returns Shutdown configuration.
+func (s *Server) ShutdownConfig() ShutdownConfig {
+	return s.ServerConfig().Shutdown
+}
+
+// ShutdownPhase represents the result of the shutdown phase.
+type ShutdownPhase struct {
+	Name     string
+	Duration time.Duration
+	Error    error
+}
+
+// waitFor polls the condition until it is true or the context is done.
+func waitFor(ctx context.Context, cond func() bool) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !cond() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// withContext runs the blocking function, returns when it is done or the context is done.
+func withContext(ctx context.Context, f func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdownPhase runs the phase with the timeout and logs the result.
+func (s *Server) shutdownPhase(name string, timeout int, f func(ctx context.Context) error) ShutdownPhase {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	}
+	defer cancel()
+
+	start := time.Now()
+	err := f(ctx)
+	phase := ShutdownPhase{Name: name, Duration: time.Since(start), Error: err}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		s.Warnf("Shutdown: %s timed out after %v", name, phase.Duration.Truncate(time.Millisecond))
+	case err != nil:
+		s.Errorf("Shutdown: %s failed after %v: %v", name, phase.Duration.Truncate(time.Millisecond), err)
+	default:
+		s.Noticef("Shutdown: %s completed in %v", name, phase.Duration.Truncate(time.Millisecond))
+	}
+	return phase
+}
+
+// stopIngestion closes WebSockets and waits for readers to finish the current message.
+func (s *Server) stopIngestion(ctx context.Context) error {
+	for _, conn := range s.WSConnections() {
+		conn.WSClose()
+	}
+	return waitFor(ctx, func() bool { return s.wsReaders.Load() == 0 })
+}
+
+// drainSinkQueues waits until sink workers deliver queued messages.
+func (s *Server) drainSinkQueues(ctx context.Context) error {
+	s.sinkMu.Lock()
+	queues := make([]*SinkQueue, 0, len(s.sinkQueues))
+	for _, q := range s.sinkQueues {
+		queues = append(queues, q)
+	}
+	s.sinkMu.Unlock()
+
+	return waitFor(ctx, func() bool {
+		for _, q := range queues {
+			if q.pending.Load() > 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// closeInfluxDB flushes pending points and closes the InfluxDB client.
+func (s *Server) closeInfluxDB(ctx context.Context) error {
+	s.mu.Lock()
+	client, writer := s.dbClient, s.dbWriter
+	s.dbClient, s.dbWriter = nil, nil
+	s.mu.Unlock()
+
+	return withContext(ctx, func() error {
+		if writer != nil {
+			writer.Flush()
+		}
+		if client != nil {
+			client.Close()
+		}
+		return nil
+	})
+}
+
+// closeMongoDB flushes batches and closes the MongoDB client.
+func (s *Server) closeMongoDB(ctx context.Context) error {
+	return withContext(ctx, func() error {
+		err := s.FlushMongoDB()
+		s.CloseMongoDB()
+		return err
+	})
+}
+
+// stopGRPC stops the GRPC server gracefully, remaining calls are cancelled on timeout.
+func (s *Server) stopGRPC(ctx context.Context) error {
+	s.mu.RLock()
+	srv, listener := s.grpcServer, s.grpcListener
+	s.mu.RUnlock()
+
+	if srv == nil {
+		return nil
+	}
+	defer listener.Close()
+
+	err := withContext(ctx, func() error {
+		srv.GracefulStop()
+		return nil
+	})
+	if err != nil {
+		srv.Stop()
+	}
+	return err
+}
+
+// stopMonitor stops the HTTP monitor.
+func (s *Server) stopMonitor(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.monitorServer
+	s.monitorServer = nil
+	s.mu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// Shutdown stops ingestion, drains sink queues, closes sinks and stops GRPC and the monitor.
+func (s *Server) Shutdown() {
+	// Prevent multiple Shutdown() calls
+	if !s.shutdown.CompareAndSwap(false, true) {
+		return
+	}
+
+	// Cancel pending operations, the cause of Fatal is kept
+	s.cancel(nil)
+
+	cfg := s.ShutdownConfig()
+	start := time.Now()
+	s.Noticef("Shutting down the server...")
+
+	report := []ShutdownPhase{
+		s.shutdownPhase("ingestion", cfg.IngestionTimeout, s.stopIngestion),
+		s.shutdownPhase("drain", cfg.DrainTimeout, s.drainSinkQueues),
+		s.shutdownPhase("nats", cfg.SinksTimeout, s.DrainNATS),
+	}
+	if s.MongoDBConfig().Enabled {
+		report = append(report, s.shutdownPhase("mongodb", cfg.SinksTimeout, s.closeMongoDB))
+	}
+	if s.InfluxDBConfig().Enabled {
+		report = append(report, s.shutdownPhase("influxdb", cfg.SinksTimeout, s.closeInfluxDB))
+	}
+
+	// Release go routines, market data streams are closed
+	close(s.quitCh)
+
+	report = append(report,
+		s.shutdownPhase("grpc", cfg.GRPCTimeout, s.stopGRPC),
+		s.shutdownPhase("monitor", cfg.MonitorTimeout, s.stopMonitor),
+	)
+
+	failed := 0
+	for _, phase := range report {
+		if phase.Error != nil {
+			failed++
+		}
+	}
+	s.Noticef("Shutdown completed in %v, %d of %d phases failed", time.Since(start).Truncate(time.Millisecond), failed, len(report))
+
+	s.mu.Lock()
+	s.shutdownReport = report
+	s.mu.Unlock()
+
+	// Notify the shutdown is complete
+	close(s.shutdownComplete)
+}
+
+// ShutdownReport returns results of the shutdown phases.
+func (s *Server) ShutdownReport() []ShutdownPhase {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shutdownReport
+}
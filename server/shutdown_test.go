@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrain(t *testing.T) {
+	var delivered atomic.Int64
+	Sinks["Delayed"] = SinkFunc(func(s *Server, m Message) error {
+		time.Sleep(time.Millisecond)
+		delivered.Add(1)
+		return nil
+	})
+	defer delete(Sinks, "Delayed")
+
+	cfg := DefaultConfig()
+	cfg.Sinks = []string{"Delayed"}
+	srv, _ := NewServer(cfg)
+	srv.startSinks()
+
+	// Queued messages are delivered before sinks are closed
+	for i := 0; i < 20; i++ {
+		srv.ProcessQuote(&Quote{})
+	}
+	srv.Shutdown()
+	expectDeepEqual(t, delivered.Load(), int64(20))
+
+	names := []string{}
+	for _, phase := range srv.ShutdownReport() {
+		expectDeepEqual(t, phase.Error, nil)
+		names = append(names, phase.Name)
+	}
+	expectDeepEqual(t, names, []string{"ingestion", "drain", "nats", "grpc", "monitor"})
+}
+
+func TestShutdownPhaseTimeout(t *testing.T) {
+	srv, _ := NewServer(DefaultConfig())
+	defer close(srv.quitCh)
+
+	phase := srv.shutdownPhase("foo", 1, func(ctx context.Context) error {
+		return waitFor(ctx, func() bool { return false })
+	})
+	expectDeepEqual(t, phase.Error, context.DeadlineExceeded)
+	expectDeepEqual(t, phase.Duration >= time.Second, true)
+}
@@ -9,7 +9,7 @@ import (
 // HandleSignals runs a goroutine to handle signals.
 func (s *Server) HandleSignals() {
 	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
 		for {
@@ -22,8 +22,13 @@ func (s *Server) HandleSignals() {
 					s.Shutdown()
 				case syscall.SIGTERM:
 					s.Shutdown()
+				case syscall.SIGHUP:
+					if err := s.Reload(); err != nil {
+						s.Errorf("Reload: %v", err)
+					}
 				}
 			case <-s.quitCh:
+				signal.Stop(sigs)
 				return
 			}
 		}
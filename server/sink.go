@@ -0,0 +1,227 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Sink delivers processed messages to the destination.
+type Sink interface {
+	Send(s *Server, m Message) error
+}
+
+// SinkFunc is an adapter to use ordinary functions as Sink.
+type SinkFunc func(s *Server, m Message) error
+
+// Send calls f(s, m).
+func (f SinkFunc) Send(s *Server, m Message) error {
+	return f(s, m)
+}
+
+var (
+	// Sinks contains registered sinks by name.
+	Sinks = map[string]Sink{}
+
+	// DefaultSinks used when sinks are not configured.
+	DefaultSinks = []string{"NATS", "MongoDB", "InfluxDB"}
+)
+
+const (
+	SinkQueuePolicyDrop  = "drop"
+	SinkQueuePolicyBlock = "block"
+)
+
+// Sink queue Configuration.
+type SinkQueueConfig struct {
+	Sink   string `xml:"Sink,attr"`
+	Size   int    `xml:"Size"`
+	Policy string `xml:"Policy"`
+}
+
+// DefaultSinkQueueConfig returns default queue config for the sink.
+func DefaultSinkQueueConfig(sink string) SinkQueueConfig {
+	return SinkQueueConfig{
+		Sink:   sink,
+		Size:   10000,
+		Policy: SinkQueuePolicyDrop,
+	}
+}
+
+// SinkQueueConfig returns queue configuration for the sink.
+func (s *Server) SinkQueueConfig(sink string) SinkQueueConfig {
+	for _, cfg := range s.ServerConfig().SinkQueues {
+		if cfg.Sink == sink {
+			return cfg
+		}
+	}
+	return DefaultSinkQueueConfig(sink)
+}
+
+// SinkQueueStats represents queue counters.
+type SinkQueueStats struct {
+	Queued   uint64 `json:"queued"`
+	Dropped  uint64 `json:"dropped"`
+	Errors   uint64 `json:"errors"`
+	Length   int    `json:"length"`
+	Capacity int    `json:"capacity"`
+}
+
+// SinkQueue is the bounded queue processed by the sink worker.
+type SinkQueue struct {
+	name   string
+	sink   Sink
+	policy string
+	ch     chan Message
+
+	queued  atomic.Uint64
+	dropped atomic.Uint64
+	errors  atomic.Uint64
+
+	// Messages queued and not delivered yet
+	pending atomic.Int64
+
+	// Time when the worker took the last message in microseconds
+	active atomic.Int64
+}
+
+// Stats returns the queue counters.
+func (q *SinkQueue) Stats() SinkQueueStats {
+	return SinkQueueStats{
+		Queued:   q.queued.Load(),
+		Dropped:  q.dropped.Load(),
+		Errors:   q.errors.Load(),
+		Length:   len(q.ch),
+		Capacity: cap(q.ch),
+	}
+}
+
+// push adds the message to the queue according to the policy.
+func (q *SinkQueue) push(s *Server, m Message) {
+	q.pending.Add(1)
+
+	if q.policy == SinkQueuePolicyBlock {
+		select {
+		case q.ch <- m:
+			q.queued.Add(1)
+		case <-s.quitCh:
+			q.pending.Add(-1)
+			q.dropped.Add(1)
+		}
+		return
+	}
+
+	select {
+	case q.ch <- m:
+		q.queued.Add(1)
+	default:
+		q.pending.Add(-1)
+		if q.dropped.Add(1) == 1 {
+			s.Warnf("Sink %s: queue is full, dropping messages", q.name)
+		}
+	}
+}
+
+// run delivers queued messages to the sink until the server quits.
+func (q *SinkQueue) run(s *Server) {
+	for {
+		select {
+		case m := <-q.ch:
+			q.active.Store(time.Now().UnixMicro())
+			if err := q.sink.Send(s, m); err != nil {
+				q.errors.Add(1)
+				s.Errorf("Sink %s: %v", q.name, err)
+			}
+			q.pending.Add(-1)
+		case <-s.quitCh:
+			return
+		}
+	}
+}
+
+// startSinks starts workers of the sink queues, queues created later are started immediately.
+func (s *Server) startSinks() {
+	s.sinkMu.Lock()
+	defer s.sinkMu.Unlock()
+
+	if s.sinksStarted {
+		return
+	}
+	s.sinksStarted = true
+	for _, q := range s.sinkQueues {
+		go q.run(s)
+	}
+}
+
+// sinkQueue returns the queue for the sink, the worker is started with the server.
+func (s *Server) sinkQueue(name string) (*SinkQueue, error) {
+	s.sinkMu.Lock()
+	defer s.sinkMu.Unlock()
+
+	if q := s.sinkQueues[name]; q != nil {
+		return q, nil
+	}
+
+	sink := Sinks[name]
+	if sink == nil {
+		return nil, fmt.Errorf("cannot find sink '%s'", name)
+	}
+
+	cfg := s.SinkQueueConfig(name)
+	if cfg.Policy != SinkQueuePolicyDrop && cfg.Policy != SinkQueuePolicyBlock {
+		return nil, fmt.Errorf("sink %s: unknown queue policy '%s'", name, cfg.Policy)
+	}
+	if cfg.Size < 1 {
+		return nil, fmt.Errorf("sink %s: invalid queue size %d", name, cfg.Size)
+	}
+
+	q := &SinkQueue{
+		name:   name,
+		sink:   sink,
+		policy: cfg.Policy,
+		ch:     make(chan Message, cfg.Size),
+	}
+	q.active.Store(time.Now().UnixMicro())
+	s.sinkQueues[name] = q
+	if s.sinksStarted {
+		go q.run(s)
+	}
+
+	return q, nil
+}
+
+// lookupSinks returns queues for the given sinks.
+func (s *Server) lookupSinks(names []string) ([]*SinkQueue, error) {
+	r := make([]*SinkQueue, 0, len(names))
+	for _, name := range names {
+		q, err := s.sinkQueue(name)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, q)
+	}
+	return r, nil
+}
+
+// sinksFor returns sinks for messages from the given source.
+func (s *Server) sinksFor(source string) []*SinkQueue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if conn := s.wsConnections[source]; conn != nil && conn.sinks != nil {
+		return conn.sinks
+	}
+	return s.sinks
+}
+
+// SinkQueueStats returns counters of all sink queues.
+func (s *Server) SinkQueueStats() map[string]SinkQueueStats {
+	s.sinkMu.Lock()
+	defer s.sinkMu.Unlock()
+
+	r := make(map[string]SinkQueueStats, len(s.sinkQueues))
+	for name, q := range s.sinkQueues {
+		r[name] = q.Stats()
+	}
+	return r
+}
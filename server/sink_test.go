@@ -0,0 +1,118 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSinks(t *testing.T) {
+	received := make(chan Message, 2)
+	Sinks["Test"] = SinkFunc(func(s *Server, m Message) error {
+		received <- m
+		return nil
+	})
+	defer delete(Sinks, "Test")
+
+	cfg := DefaultConfig()
+	cfg.WebSocket = []WSConfig{
+		{Name: "foo", Enabled: true, Sinks: []string{"Test"}},
+		{Name: "bar", Enabled: true},
+	}
+
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.startSinks()
+	defer s.Shutdown()
+
+	foo := &Candle{MessageHeader: MessageHeader{Source: "foo"}}
+	bar := &Quote{MessageHeader: MessageHeader{Source: "bar"}}
+	s.ProcessCandle(foo)
+	s.ProcessQuote(bar)
+
+	select {
+	case m := <-received:
+		expectDeepEqual(t, m, Message(foo))
+	case <-time.After(time.Second):
+		t.Fatalf("Message was not delivered")
+	}
+
+	select {
+	case m := <-received:
+		t.Fatalf("Unexpected message %v", m)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestUnknownSink(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Sinks = []string{"Unknown"}
+
+	if _, err := NewServer(cfg); err == nil {
+		t.Fatalf("Expected error for unknown sink")
+	}
+}
+
+func TestSinkQueueDrop(t *testing.T) {
+	release := make(chan struct{})
+	Sinks["Slow"] = SinkFunc(func(s *Server, m Message) error {
+		<-release
+		return nil
+	})
+	defer delete(Sinks, "Slow")
+
+	cfg := DefaultConfig()
+	cfg.Sinks = []string{"Slow"}
+	cfg.SinkQueues = []SinkQueueConfig{{Sink: "Slow", Size: 1, Policy: SinkQueuePolicyDrop}}
+
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.startSinks()
+	defer s.Shutdown()
+	defer close(release)
+
+	// The first message blocks the worker, the second one is queued, the rest are dropped
+	s.ProcessCandle(&Candle{})
+	for s.SinkQueueStats()["Slow"].Length > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 4; i++ {
+		s.ProcessCandle(&Candle{})
+	}
+
+	stats := s.SinkQueueStats()["Slow"]
+	expectDeepEqual(t, stats.Queued, uint64(2))
+	expectDeepEqual(t, stats.Dropped, uint64(3))
+}
+
+func TestSinkWorkersStart(t *testing.T) {
+	delivered := make(chan Message, 1)
+	Sinks["Test"] = SinkFunc(func(s *Server, m Message) error {
+		delivered <- m
+		return nil
+	})
+	defer delete(Sinks, "Test")
+
+	cfg := DefaultConfig()
+	cfg.Sinks = []string{"Test"}
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Messages are queued until the workers are started
+	s.ProcessQuote(&Quote{})
+	time.Sleep(10 * time.Millisecond)
+	expectDeepEqual(t, len(delivered), 0)
+
+	s.startSinks()
+	defer s.Shutdown()
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatalf("Message was not delivered")
+	}
+}
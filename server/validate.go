@@ -0,0 +1,254 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// validator collects configuration errors.
+type validator struct {
+	errors []error
+}
+
+// errorf adds the error.
+func (v *validator) errorf(format string, a ...any) {
+	v.errors = append(v.errors, fmt.Errorf(format, a...))
+}
+
+// url checks that the URL is absolute and has one of the schemes.
+func (v *validator) url(name string, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		v.errorf("%s: invalid URL: %v", name, err)
+		return
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return
+		}
+	}
+	v.errorf("%s: invalid URL '%s', expected %s://host", name, value, strings.Join(schemes, ":// or "))
+}
+
+// nonNegative checks that the values are not negative.
+func (v *validator) nonNegative(section string, values map[string]float64) {
+	for _, name := range sortedKeys(values) {
+		if values[name] < 0 {
+			v.errorf("%s: %s must not be negative", section, name)
+		}
+	}
+}
+
+// tls checks that the certificate and key can be loaded.
+func (v *validator) tls(name string, enabled bool, cert string, key string) {
+	if !enabled {
+		return
+	}
+	if _, err := tls.LoadX509KeyPair(cert, key); err != nil {
+		v.errorf("%s: cannot load TLS certificate: %v", name, err)
+	}
+}
+
+// file checks that the file is readable if set.
+func (v *validator) file(name string, path string) {
+	if path == "" {
+		return
+	}
+	if _, err := os.ReadFile(path); err != nil {
+		v.errorf("%s: %v", name, err)
+	}
+}
+
+// sinks checks that the sinks are registered.
+func (v *validator) sinks(name string, sinks []string) {
+	for _, sink := range sinks {
+		if Sinks[sink] == nil {
+			v.errorf("%s: unknown sink '%s'", name, sink)
+		}
+	}
+}
+
+// intervals checks that the intervals can be parsed.
+func (v *validator) intervals(name string, intervals []string) {
+	for _, interval := range intervals {
+		if _, err := ParseInterval(interval); err != nil {
+			v.errorf("%s: %v", name, err)
+		}
+	}
+}
+
+// bindConflict returns whether both addresses listen on the same port.
+func bindConflict(a string, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(a)
+	hostB, portB, errB := net.SplitHostPort(b)
+	if errA != nil || errB != nil || portA != portB || portA == "0" {
+		return false
+	}
+
+	unspecified := func(host string) bool {
+		ip := net.ParseIP(host)
+		return host == "" || (ip != nil && ip.IsUnspecified())
+	}
+	return hostA == hostB || unspecified(hostA) || unspecified(hostB)
+}
+
+// Validate checks the configuration and returns all errors.
+func (c ServerConfig) Validate() []error {
+	v := &validator{}
+
+	// Monitor and GRPC
+	if _, _, err := net.SplitHostPort(c.Monitor.Bind); err != nil {
+		v.errorf("Monitor: invalid Bind: %v", err)
+	}
+	if _, _, err := net.SplitHostPort(c.GRPC.Bind); err != nil {
+		v.errorf("GRPC: invalid Bind: %v", err)
+	}
+	if bindConflict(c.Monitor.Bind, c.GRPC.Bind) {
+		v.errorf("Monitor and GRPC bind to the same address %s", c.GRPC.Bind)
+	}
+	v.tls("Monitor", c.Monitor.TLS, c.Monitor.TLSCertificate, c.Monitor.TLSKey)
+	v.tls("GRPC", c.GRPC.TLS, c.GRPC.TLSCertificate, c.GRPC.TLSKey)
+	v.nonNegative("Monitor", map[string]float64{"LivenessTimeout": float64(c.Monitor.LivenessTimeout)})
+	v.nonNegative("GRPC", map[string]float64{"HistoryLimit": float64(c.GRPC.HistoryLimit)})
+	if c.GRPC.StreamBuffer < 1 {
+		v.errorf("GRPC: StreamBuffer must be positive")
+	}
+
+	// Clients
+	for _, u := range strings.Split(c.NATS.ServerURLs(), ",") {
+		v.url("NATS", strings.TrimSpace(u), "nats", "tls", "ws", "wss")
+	}
+	v.nonNegative("NATS", map[string]float64{
+		"RetryDelay":    float64(c.NATS.RetryDelay),
+		"ReconnectWait": float64(c.NATS.ReconnectWait),
+		"PingInterval":  float64(c.NATS.PingInterval),
+	})
+	auth := 0
+	for _, value := range []string{c.NATS.User, c.NATS.Token, c.NATS.NKeySeed, c.NATS.Credentials} {
+		if value != "" {
+			auth++
+		}
+	}
+	if auth > 1 {
+		v.errorf("NATS: User, Token, NKeySeed and Credentials are mutually exclusive")
+	}
+	if c.NATS.Password != "" && c.NATS.User == "" {
+		v.errorf("NATS: Password requires User")
+	}
+	v.file("NATS NKeySeed", c.NATS.NKeySeed)
+	v.file("NATS Credentials", c.NATS.Credentials)
+	v.file("NATS TLSCA", c.NATS.TLSCA)
+	v.tls("NATS", c.NATS.TLSCertificate != "" || c.NATS.TLSKey != "", c.NATS.TLSCertificate, c.NATS.TLSKey)
+	if c.NATS.JetStream.Enabled {
+		v.nonNegative("NATS JetStream", map[string]float64{
+			"AckTimeout": float64(c.NATS.JetStream.AckTimeout),
+			"MaxPending": float64(c.NATS.JetStream.MaxPending),
+		})
+		for _, stream := range c.NATS.JetStream.JetStreamStreams() {
+			if _, err := stream.StreamConfig(); err != nil {
+				v.errorf("NATS JetStream: %v", err)
+			}
+			v.nonNegative("NATS JetStream "+stream.Name, map[string]float64{
+				"MaxAge":          float64(stream.MaxAge),
+				"DuplicateWindow": float64(stream.DuplicateWindow),
+			})
+		}
+	}
+	if c.MongoDB.Enabled {
+		v.url("MongoDB", c.MongoDB.URL, "mongodb", "mongodb+srv")
+	}
+	v.nonNegative("MongoDB", map[string]float64{
+		"RetryDelay":    float64(c.MongoDB.RetryDelay),
+		"BatchSize":     float64(c.MongoDB.BatchSize),
+		"FlushInterval": float64(c.MongoDB.FlushInterval),
+		"MaxBuffer":     float64(c.MongoDB.MaxBuffer),
+		"WriteTimeout":  float64(c.MongoDB.WriteTimeout),
+	})
+	if c.InfluxDB.Enabled {
+		v.url("InfluxDB", c.InfluxDB.URL, "http", "https")
+	}
+	v.nonNegative("Reconnect", map[string]float64{
+		"MaxDelay":    float64(c.Reconnect.MaxDelay),
+		"Multiplier":  c.Reconnect.Multiplier,
+		"Jitter":      c.Reconnect.Jitter,
+		"MaxAttempts": float64(c.Reconnect.MaxAttempts),
+		"StableAfter": float64(c.Reconnect.StableAfter),
+	})
+	v.nonNegative("Shutdown", map[string]float64{
+		"IngestionTimeout": float64(c.Shutdown.IngestionTimeout),
+		"DrainTimeout":     float64(c.Shutdown.DrainTimeout),
+		"SinksTimeout":     float64(c.Shutdown.SinksTimeout),
+		"GRPCTimeout":      float64(c.Shutdown.GRPCTimeout),
+		"MonitorTimeout":   float64(c.Shutdown.MonitorTimeout),
+	})
+	if c.Reconnect.Jitter > 1 {
+		v.errorf("Reconnect: Jitter must not be greater than 1")
+	}
+
+	// Sinks
+	v.sinks("Sink", c.Sinks)
+	for _, q := range c.SinkQueues {
+		v.sinks("SinkQueue", []string{q.Sink})
+		if q.Policy != SinkQueuePolicyDrop && q.Policy != SinkQueuePolicyBlock {
+			v.errorf("SinkQueue %s: unknown policy '%s'", q.Sink, q.Policy)
+		}
+		if q.Size < 1 {
+			v.errorf("SinkQueue %s: Size must be positive", q.Sink)
+		}
+	}
+	if c.Aggregator.Enabled {
+		v.intervals("Aggregator", c.Aggregator.Intervals)
+	}
+
+	// WebSockets
+	components := map[string]bool{ComponentNATS: true, ComponentMongoDB: true, ComponentInfluxDB: true}
+	names := map[string]bool{}
+	for i, ws := range c.WebSocket {
+		name := fmt.Sprintf("WebSocket %s", ws.Name)
+		if ws.Name == "" {
+			name = fmt.Sprintf("WebSocket #%d", i+1)
+			v.errorf("%s: Name is required", name)
+		} else if names[ws.Name] {
+			v.errorf("%s: duplicate Name", name)
+		}
+		names[ws.Name] = true
+		if ws.Enabled {
+			components[wsComponent(ws.Name)] = true
+		}
+
+		if Handlers[ws.Handler] == nil {
+			v.errorf("%s: unknown handler '%s'", name, ws.Handler)
+		}
+		v.url(name, ws.URL, "ws", "wss")
+		// Empty URL is the default REST API of the handler
+		if ws.OrderBook.Enabled && ws.OrderBook.URL != "" {
+			v.url(name+" OrderBook", ws.OrderBook.URL, "http", "https")
+		}
+		v.nonNegative(name, map[string]float64{
+			"DialTimeout": float64(ws.DialTimeout),
+			"RetryDelay":  float64(ws.RetryDelay),
+			"PingTimeout": float64(ws.PingTimeout),
+			"MaxSilence":  float64(ws.MaxSilence),
+			"ReadLimit":   float64(ws.ReadLimit),
+		})
+		for _, sym := range ws.SymbolSilence {
+			if sym.MaxSilence < 0 {
+				v.errorf("%s: SymbolSilence %s must not be negative", name, sym.Symbol)
+			}
+		}
+		v.sinks(name, ws.Sinks)
+		v.intervals(name, ws.CandleIntervals)
+	}
+
+	for _, required := range c.Monitor.Required {
+		if !components[required] {
+			v.errorf("Monitor: unknown required component '%s'", required)
+		}
+	}
+	return v.errors
+}
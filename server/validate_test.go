@@ -0,0 +1,56 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestValidateSampleConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := LoadConfig("../stockmq-server.xml", &cfg); err != nil {
+		t.Fatal(err)
+	}
+	expectDeepEqual(t, cfg.Validate(), []error(nil))
+}
+
+func TestValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.GRPC.Bind = "0.0.0.0:9100"
+	cfg.GRPC.TLS = true
+	cfg.Monitor.Required = []string{"ws/baz"}
+	cfg.WebSocket = []WSConfig{
+		{Name: "foo", Enabled: true, URL: "wss://localhost", Handler: "Binance"},
+		{Name: "foo", Enabled: true, URL: "localhost", Handler: "Binanse", DialTimeout: -1},
+		{Enabled: false, URL: "wss://localhost", Handler: "Debug"},
+	}
+
+	errs := []string{}
+	for _, err := range cfg.Validate() {
+		errs = append(errs, err.Error())
+	}
+	expectDeepEqual(t, errs, []string{
+		"Monitor and GRPC bind to the same address 0.0.0.0:9100",
+		"GRPC: cannot load TLS certificate: open : no such file or directory",
+		"WebSocket foo: duplicate Name",
+		"WebSocket foo: unknown handler 'Binanse'",
+		"WebSocket foo: invalid URL 'localhost', expected ws:// or wss://host",
+		"WebSocket foo: DialTimeout must not be negative",
+		"WebSocket #3: Name is required",
+		"Monitor: unknown required component 'ws/baz'",
+	})
+}
+
+func TestValidateOrderBook(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.WebSocket = []WSConfig{
+		{Name: "foo", Enabled: true, URL: "wss://localhost", Handler: "Binance", OrderBook: OrderBookConfig{Enabled: true}},
+		{Name: "bar", Enabled: true, URL: "wss://localhost", Handler: "Binance", OrderBook: OrderBookConfig{Enabled: true, URL: "localhost"}},
+	}
+
+	errs := []string{}
+	for _, err := range cfg.Validate() {
+		errs = append(errs, err.Error())
+	}
+	expectDeepEqual(t, errs, []string{
+		"WebSocket bar OrderBook: invalid URL 'localhost', expected http:// or https://host",
+	})
+}
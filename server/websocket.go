@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -14,25 +15,154 @@ type WSMsgHandler func(s *Server, w *WSConnection, msg []byte) error
 
 // WebSocket Configuration.
 type WSConfig struct {
-	Name         string   `xml:"Name"`
-	Enabled      bool     `xml:"Enabled"`
-	URL          string   `xml:"URL"`
-	Handler      string   `xml:"Handler"`
-	DialTimeout  int      `xml:"DialTimeout"`
-	RetryDelay   int      `xml:"RetryDelay"`
-	PingTimeout  int      `xml:"PingTimeout"`
-	ReadLimit    int64    `xml:"ReadLimit"`
-	Headers      []Header `xml:"Header"`
-	InitMessages []string `xml:"InitMessage"`
+	Name            string          `xml:"Name"`
+	Enabled         bool            `xml:"Enabled"`
+	URL             string          `xml:"URL"`
+	Handler         string          `xml:"Handler"`
+	DialTimeout     int             `xml:"DialTimeout"`
+	RetryDelay      int             `xml:"RetryDelay"`
+	PingTimeout     int             `xml:"PingTimeout"`
+	MaxSilence      int             `xml:"MaxSilence"`
+	ReadLimit       int64           `xml:"ReadLimit"`
+	Headers         []Header        `xml:"Header"`
+	InitMessages    []string        `xml:"InitMessage"`
+	OrderBook       OrderBookConfig `xml:"OrderBook"`
+	CandleIntervals []string        `xml:"CandleInterval"`
+	Sinks           []string        `xml:"Sink"`
+
+	SymbolSilence []SymbolSilenceConfig `xml:"SymbolSilence"`
 }
 
 var (
 	Handlers = map[string]WSMsgHandler{}
 )
 
+// WebSocket connection states.
+const (
+	WSStateConnected    = "connected"
+	WSStateDisconnected = "disconnected"
+	WSStatePaused       = "paused"
+)
+
 // IsWSReconnecting returns whether websocket is scheduled to reconnect.
 func (c *WSConnection) IsWSReconnecting() bool {
-	return c.wsReconn.Load()
+	return c.reconnect.IsWaiting()
+}
+
+// IsWSPaused returns whether websocket is paused.
+func (c *WSConnection) IsWSPaused() bool {
+	return c.reconnect.IsPaused()
+}
+
+// Config returns the connection configuration.
+func (c *WSConnection) Config() WSConfig {
+	c.RLock()
+	defer c.RUnlock()
+	return c.wsConfig
+}
+
+// State returns the connection state.
+func (c *WSConnection) State() string {
+	if c.IsWSPaused() {
+		return WSStatePaused
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+	if c.wsConn != nil {
+		return WSStateConnected
+	}
+	return WSStateDisconnected
+}
+
+// LastMessage returns time of the last received message.
+func (c *WSConnection) LastMessage() time.Time {
+	if t := c.wsLastMessage.Load(); t > 0 {
+		return time.UnixMicro(t)
+	}
+	return time.Time{}
+}
+
+// newWSConnection creates the connection, it is started by StartWS.
+func (s *Server) newWSConnection(cfg WSConfig) (*WSConnection, error) {
+	conn := &WSConnection{wsConfig: cfg, subscriptions: make(map[string]struct{}), symbolLastData: make(map[string]int64)}
+	conn.reconnect = s.newReconnector(wsComponent(cfg.Name), "WSS "+cfg.Name,
+		func() int { return conn.Config().RetryDelay }, conn.WSClose, func() { s.StartWS(conn) })
+
+	if len(cfg.CandleIntervals) > 0 {
+		builder, err := NewCandleBuilder(cfg.CandleIntervals)
+		if err != nil {
+			return nil, err
+		}
+		conn.candleBuilder = builder
+	}
+
+	if len(cfg.Sinks) > 0 {
+		sinks, err := s.lookupSinks(cfg.Sinks)
+		if err != nil {
+			return nil, err
+		}
+		conn.sinks = sinks
+	}
+	return conn, nil
+}
+
+// WSConnection returns the connection by name.
+func (s *Server) WSConnection(name string) *WSConnection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.wsConnections[name]
+}
+
+// WSConnections returns all connections sorted by name.
+func (s *Server) WSConnections() []*WSConnection {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r := make([]*WSConnection, 0, len(s.wsConnections))
+	for _, conn := range s.wsConnections {
+		r = append(r, conn)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].wsConfig.Name < r[j].wsConfig.Name })
+	return r
+}
+
+// WSClose closes the underlying websocket.
+func (c *WSConnection) WSClose() {
+	c.Lock()
+	defer c.Unlock()
+	if c.wsConn != nil {
+		c.wsConn.Close()
+		c.wsConn = nil
+	}
+}
+
+// WSReconnect closes the websocket and schedules reconnect, attempts are reset.
+func (s *Server) WSReconnect(conn *WSConnection) error {
+	if !conn.reconnect.Reset() {
+		return fmt.Errorf("connection %s is paused", conn.wsConfig.Name)
+	}
+	s.WSHandleError(conn, fmt.Errorf("reconnect requested"))
+	return nil
+}
+
+// WSPause closes the websocket, it is not reconnected until resumed.
+func (s *Server) WSPause(conn *WSConnection) {
+	if !conn.reconnect.Pause() {
+		return
+	}
+
+	s.Noticef("WSS %s: Paused", conn.wsConfig.Name)
+	conn.WSClose()
+	s.componentDown(wsComponent(conn.wsConfig.Name), fmt.Errorf("paused"))
+}
+
+// WSResume starts the paused websocket.
+func (s *Server) WSResume(conn *WSConnection) {
+	if conn.reconnect.Resume() {
+		s.Noticef("WSS %s: Resumed", conn.wsConfig.Name)
+		go s.StartWS(conn)
+	}
 }
 
 // WSKeepAlive enabled Ping-Pong with given timeout.
@@ -97,7 +227,7 @@ func (s *Server) StartWS(conn *WSConnection) {
 	}
 
 	// Dial WebSocket
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.DialTimeout)*time.Second)
+	ctx, cancel := context.WithTimeout(s.ctx, time.Duration(cfg.DialTimeout)*time.Second)
 	defer cancel()
 	c, _, err := websocket.DefaultDialer.DialContext(ctx, cfg.URL, headers)
 	if err != nil {
@@ -105,23 +235,46 @@ func (s *Server) StartWS(conn *WSConnection) {
 		return
 	}
 
+	// The connection is paused or the server is shutting down while dialing
 	conn.Lock()
+	if conn.IsWSPaused() || s.IsShutdown() {
+		conn.Unlock()
+		c.Close()
+		return
+	}
 	conn.wsConn = c
 	conn.wsConn.SetReadLimit(cfg.ReadLimit)
 	conn.Unlock()
+	conn.reconnect.Connected()
+	s.componentUp(wsComponent(cfg.Name))
+
+	// Local order books must be resynced after reconnect
+	conn.resetDepthBooks()
 
 	s.WSKeepAlive(cfg, c)
+	s.WSWatchdog(conn, c)
+	s.WSCandleFlusher(conn, c)
 
 	// Send init messages
+	messages := make([][]byte, 0, len(cfg.InitMessages))
 	for _, msg := range cfg.InitMessages {
-		if err := c.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
-			s.WSHandleError(conn, err)
-			return
-		}
+		messages = append(messages, []byte(msg))
+	}
+	if err := conn.wsWrite(c, messages); err != nil {
+		s.WSHandleError(conn, err)
+		return
+	}
+
+	// Restore subscriptions added at runtime
+	if err := s.wsReplaySubscriptions(conn, c); err != nil {
+		s.WSHandleError(conn, err)
+		return
 	}
 
-	// Run goroutine to process incoming messages
+	// Run goroutine to process incoming messages, shutdown waits for readers
+	s.wsReaders.Add(1)
 	go func() {
+		defer s.wsReaders.Add(-1)
 		defer c.Close()
 
 		for {
@@ -130,8 +283,10 @@ func (s *Server) StartWS(conn *WSConnection) {
 				s.WSHandleError(conn, err)
 				return
 			}
+			conn.wsLastMessage.Store(time.Now().UnixMicro())
 
 			if err := handler(s, conn, raw); err != nil {
+				s.metrics.HandlerErrors.Inc(cfg.Name, cfg.Handler)
 				s.WSHandleError(conn, err)
 			}
 		}
@@ -140,32 +295,14 @@ func (s *Server) StartWS(conn *WSConnection) {
 
 // WSHandleError handles the error.
 func (s *Server) WSHandleError(conn *WSConnection, err error) {
-	// Do nothing if the server is shutting down or WebSocket is reconnecting.
-	if s.IsShutdown() || conn.IsWSReconnecting() {
+	// Do nothing if the server is shutting down or the connection was removed by reload
+	if s.IsShutdown() || s.WSConnection(conn.wsConfig.Name) != conn {
 		return
 	}
+	s.componentDown(wsComponent(conn.wsConfig.Name), err)
 
-	s.Errorf("WSS %s: %v", conn.wsConfig.Name, err)
-
-	conn.Lock()
-	if conn.wsConn != nil {
-		conn.wsConn.Close()
-		conn.wsConn = nil
+	// Close WebSocket and restart it with backoff unless paused or reconnecting
+	if conn.reconnect.Fail(err) {
+		s.metrics.WSReconnects.Inc(conn.wsConfig.Name)
 	}
-	cfg := conn.wsConfig
-	conn.Unlock()
-
-	// Runs goroutine to restart WebSocket connection after RetryDelay
-	go func() {
-		conn.wsReconn.Store(true)
-		s.Noticef("WSS %s: Reconnecting in %d seconds", cfg.Name, cfg.RetryDelay)
-
-		select {
-		case <-s.quitCh:
-			return
-		case <-time.After(time.Duration(cfg.RetryDelay) * time.Second):
-			conn.wsReconn.Store(false)
-			s.StartWS(conn)
-		}
-	}()
 }
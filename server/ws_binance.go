@@ -2,12 +2,15 @@ package server
 
 import (
 	"encoding/json"
+	"strconv"
 	"time"
 )
 
 const (
 	binanceEventKline       = "kline"
 	binenceEventDepthUpdate = "depthUpdate"
+	binanceEventTrade       = "trade"
+	binanceEventAggTrade    = "aggTrade"
 )
 
 type BinanceMessage struct {
@@ -22,6 +25,12 @@ type BinanceResult struct {
 	ID     int          `json:"id"`
 }
 
+type BinanceRequest struct {
+	ID     int64    `json:"id"`
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+}
+
 type BinanceError struct {
 	Code    int    `json:"code"`
 	Message string `json:"msg"`
@@ -64,6 +73,40 @@ type BinanceOrderBook struct {
 	Asks          [][]string `json:"a"`
 }
 
+type BinanceTrade struct {
+	EventType  string `json:"e"`
+	EventTime  int64  `json:"E"`
+	Symbol     string `json:"s"`
+	TradeID    int64  `json:"t"`
+	Price      string `json:"p"`
+	Quantity   string `json:"q"`
+	TradeTime  int64  `json:"T"`
+	BuyerMaker bool   `json:"m"`
+	Ignore     bool   `json:"M"`
+}
+
+type BinanceAggTrade struct {
+	EventType  string `json:"e"`
+	EventTime  int64  `json:"E"`
+	Symbol     string `json:"s"`
+	AggTradeID int64  `json:"a"`
+	Price      string `json:"p"`
+	Quantity   string `json:"q"`
+	FirstID    int64  `json:"f"`
+	LastID     int64  `json:"l"`
+	TradeTime  int64  `json:"T"`
+	BuyerMaker bool   `json:"m"`
+	Ignore     bool   `json:"M"`
+}
+
+// binanceTradeSide returns the taker side.
+func binanceTradeSide(buyerMaker bool) string {
+	if buyerMaker {
+		return TradeSideSell
+	}
+	return TradeSideBuy
+}
+
 // WSBinanceHandler process message from the binance stream.
 func WSBinanceHandler(s *Server, w *WSConnection, msg []byte) error {
 	rcv := time.Now()
@@ -97,6 +140,7 @@ func WSBinanceHandler(s *Server, w *WSConnection, msg []byte) error {
 				Low:      c.Kline.Low,
 				Close:    c.Kline.Close,
 				Volume:   c.Kline.Volume,
+				Final:    c.Kline.IsFinal,
 			}
 
 			return s.ProcessCandle(r)
@@ -106,6 +150,14 @@ func WSBinanceHandler(s *Server, w *WSConnection, msg []byte) error {
 				return err
 			}
 
+			// Maintain the local order book and publish its state
+			if w.wsConfig.OrderBook.Enabled {
+				if r := w.binanceDepthBook(c.Symbol).update(s, w, c, rcv); r != nil {
+					return s.ProcessQuote(r)
+				}
+				return nil
+			}
+
 			r := &Quote{
 				MessageHeader: MessageHeader{
 					Symbol:  c.Symbol,
@@ -122,6 +174,52 @@ func WSBinanceHandler(s *Server, w *WSConnection, msg []byte) error {
 			}
 
 			return s.ProcessQuote(r)
+		case binanceEventTrade:
+			c := &BinanceTrade{}
+			if err := json.Unmarshal(msg, c); err != nil {
+				return err
+			}
+
+			r := &Trade{
+				MessageHeader: MessageHeader{
+					Symbol:  c.Symbol,
+					Time:    c.TradeTime * 1000,
+					TimeSrv: c.EventTime * 1000,
+					TimeRcv: rcv.UnixMicro(),
+					Source:  w.wsConfig.Name,
+				},
+
+				TradeID:    strconv.FormatInt(c.TradeID, 10),
+				Price:      c.Price,
+				Quantity:   c.Quantity,
+				Side:       binanceTradeSide(c.BuyerMaker),
+				BuyerMaker: c.BuyerMaker,
+			}
+
+			return s.ProcessTrade(r)
+		case binanceEventAggTrade:
+			c := &BinanceAggTrade{}
+			if err := json.Unmarshal(msg, c); err != nil {
+				return err
+			}
+
+			r := &Trade{
+				MessageHeader: MessageHeader{
+					Symbol:  c.Symbol,
+					Time:    c.TradeTime * 1000,
+					TimeSrv: c.EventTime * 1000,
+					TimeRcv: rcv.UnixMicro(),
+					Source:  w.wsConfig.Name,
+				},
+
+				TradeID:    strconv.FormatInt(c.AggTradeID, 10),
+				Price:      c.Price,
+				Quantity:   c.Quantity,
+				Side:       binanceTradeSide(c.BuyerMaker),
+				BuyerMaker: c.BuyerMaker,
+			}
+
+			return s.ProcessTrade(r)
 		default:
 			s.Errorf("WSS %s: unknown event '%s'", w.wsConfig.Name, *message.EventType)
 		}
@@ -143,6 +241,16 @@ func WSBinanceHandler(s *Server, w *WSConnection, msg []byte) error {
 	return nil
 }
 
+// BinanceSubscriptionRequests builds SUBSCRIBE or UNSUBSCRIBE request for the streams (e.g. btcusdt@kline_1m).
+func BinanceSubscriptionRequests(id int64, subscribe bool, streams []string) ([][]byte, error) {
+	method := "UNSUBSCRIBE"
+	if subscribe {
+		method = "SUBSCRIBE"
+	}
+	return jsonMessages(&BinanceRequest{ID: id, Method: method, Params: streams})
+}
+
 func init() {
 	Handlers["Binance"] = WSBinanceHandler
+	SubscriptionBuilders["Binance"] = BinanceSubscriptionRequests
 }
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	binanceDefaultURL          = "https://api.binance.com"
+	binanceDefaultDepthLimit   = 1000
+	binanceDefaultDepthTimeout = 10
+	binanceMaxPendingUpdates   = 10000
+)
+
+type BinanceDepthSnapshot struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+}
+
+// BinanceDepthBook keeps the local order book in sync with the depth stream.
+type BinanceDepthBook struct {
+	sync.Mutex
+
+	book         *OrderBook
+	lastUpdateID int64
+	synced       bool
+	applied      bool
+	fetching     bool
+	closed       bool
+	pending      []*BinanceOrderBook
+}
+
+// binanceDepthBook returns the depth book for the symbol.
+func (c *WSConnection) binanceDepthBook(symbol string) *BinanceDepthBook {
+	c.depthMu.Lock()
+	defer c.depthMu.Unlock()
+
+	if c.depthBooks == nil {
+		c.depthBooks = make(map[string]*BinanceDepthBook)
+	}
+
+	b := c.depthBooks[symbol]
+	if b == nil {
+		b = &BinanceDepthBook{book: NewOrderBook(symbol)}
+		c.depthBooks[symbol] = b
+	}
+	return b
+}
+
+// resetDepthBooks drops all depth books, pending snapshots are discarded.
+func (c *WSConnection) resetDepthBooks() {
+	c.depthMu.Lock()
+	defer c.depthMu.Unlock()
+
+	for _, b := range c.depthBooks {
+		b.Lock()
+		b.closed = true
+		b.Unlock()
+	}
+	c.depthBooks = nil
+}
+
+// BinanceFetchDepth requests the depth snapshot from the REST API.
+func BinanceFetchDepth(cfg OrderBookConfig, symbol string) (*BinanceDepthSnapshot, error) {
+	base := cfg.URL
+	if base == "" {
+		base = binanceDefaultURL
+	}
+	limit := cfg.Limit
+	if limit < 1 {
+		limit = binanceDefaultDepthLimit
+	}
+	timeout := cfg.Timeout
+	if timeout < 1 {
+		timeout = binanceDefaultDepthTimeout
+	}
+
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("limit", strconv.Itoa(limit))
+
+	client := &http.Client{Timeout: time.Duration(timeout) * time.Second}
+	resp, err := client.Get(base + "/api/v3/depth?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("depth snapshot for %s: %s", symbol, resp.Status)
+	}
+
+	r := &BinanceDepthSnapshot{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// quote returns the Quote for the current state of the book.
+func (b *BinanceDepthBook) quote(w *WSConnection, eventTime int64, rcv time.Time) *Quote {
+	r := b.book.Quote(w.wsConfig.OrderBook.Levels)
+	r.Time = eventTime * 1000
+	r.TimeSrv = eventTime * 1000
+	r.TimeRcv = rcv.UnixMicro()
+	r.Source = w.wsConfig.Name
+	return r
+}
+
+// resync drops the book state and requests a new snapshot. Must be called with the lock held.
+func (b *BinanceDepthBook) resync(s *Server, w *WSConnection) {
+	b.synced = false
+	b.applied = false
+	b.book.Reset()
+	b.startFetch(s, w)
+}
+
+// startFetch starts loading the snapshot unless it is already loading. Must be called with the lock held.
+func (b *BinanceDepthBook) startFetch(s *Server, w *WSConnection) {
+	if b.fetching {
+		return
+	}
+
+	b.fetching = true
+	w.depthFetches.Add(1)
+	go func() {
+		defer w.depthFetches.Done()
+		b.fetch(s, w)
+	}()
+}
+
+// apply applies the update to the synced book. Must be called with the lock held.
+func (b *BinanceDepthBook) apply(u *BinanceOrderBook) error {
+	if !b.applied {
+		// The first processed event should contain lastUpdateId + 1
+		if u.FirstUpdateID > b.lastUpdateID+1 || u.LastUpdateID < b.lastUpdateID+1 {
+			return fmt.Errorf("depth update %d-%d does not follow snapshot %d", u.FirstUpdateID, u.LastUpdateID, b.lastUpdateID)
+		}
+	} else if u.FirstUpdateID != b.lastUpdateID+1 {
+		return fmt.Errorf("depth update gap %d-%d after %d", u.FirstUpdateID, u.LastUpdateID, b.lastUpdateID)
+	}
+
+	if err := b.book.Apply(u.Bids, u.Asks); err != nil {
+		return err
+	}
+
+	b.lastUpdateID = u.LastUpdateID
+	b.applied = true
+	return nil
+}
+
+// fetch loads the snapshot and applies pending updates, retries until succeeded.
+func (b *BinanceDepthBook) fetch(s *Server, w *WSConnection) {
+	cfg := w.wsConfig
+	symbol := b.book.Symbol
+
+	delay := time.Duration(cfg.RetryDelay) * time.Second
+	if delay < time.Second {
+		delay = time.Second
+	}
+
+	for {
+		s.Debugf("WSS %s: requesting depth snapshot for %s", cfg.Name, symbol)
+
+		snapshot, err := BinanceFetchDepth(cfg.OrderBook, symbol)
+		if err == nil {
+			var q *Quote
+			if q, err = b.load(w, snapshot, time.Now()); err == nil {
+				if q != nil {
+					s.ProcessQuote(q)
+				}
+				return
+			}
+		}
+
+		s.Errorf("WSS %s: %s depth snapshot: %v", cfg.Name, symbol, err)
+
+		select {
+		case <-s.quitCh:
+			return
+		case <-time.After(delay):
+		}
+
+		b.Lock()
+		closed := b.closed
+		b.Unlock()
+		if closed {
+			return
+		}
+	}
+}
+
+// load seeds the book from the snapshot and applies pending updates.
+func (b *BinanceDepthBook) load(w *WSConnection, snapshot *BinanceDepthSnapshot, rcv time.Time) (*Quote, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		b.fetching = false
+		return nil, nil
+	}
+
+	b.book.Reset()
+	if err := b.book.Apply(snapshot.Bids, snapshot.Asks); err != nil {
+		return nil, err
+	}
+	b.lastUpdateID = snapshot.LastUpdateID
+	b.applied = false
+
+	pending := b.pending
+	var eventTime int64
+	for i, u := range pending {
+		// Drop any event where u is <= lastUpdateId in the snapshot
+		if u.LastUpdateID <= b.lastUpdateID {
+			continue
+		}
+
+		if err := b.apply(u); err != nil {
+			// Buffered events do not follow the snapshot, keep them and try again
+			b.pending = pending[i:]
+			return nil, err
+		}
+		eventTime = u.EventType
+	}
+
+	b.pending = nil
+	b.fetching = false
+	b.synced = true
+
+	if eventTime == 0 {
+		eventTime = rcv.UnixMilli()
+	}
+	return b.quote(w, eventTime, rcv), nil
+}
+
+// update applies the diff or buffers it until the snapshot is loaded.
+func (b *BinanceDepthBook) update(s *Server, w *WSConnection, u *BinanceOrderBook, rcv time.Time) *Quote {
+	b.Lock()
+	defer b.Unlock()
+
+	if !b.synced {
+		if len(b.pending) >= binanceMaxPendingUpdates {
+			b.pending = b.pending[1:]
+		}
+		b.pending = append(b.pending, u)
+		b.startFetch(s, w)
+		return nil
+	}
+
+	// Outdated update
+	if u.LastUpdateID <= b.lastUpdateID {
+		return nil
+	}
+
+	if err := b.apply(u); err != nil {
+		s.Warnf("WSS %s: %s %v, resyncing", w.wsConfig.Name, u.Symbol, err)
+		b.pending = []*BinanceOrderBook{u}
+		b.resync(s, w)
+		return nil
+	}
+
+	return b.quote(w, u.EventType, rcv)
+}
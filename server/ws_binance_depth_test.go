@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBinanceDepthBook(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/depth" || r.URL.Query().Get("symbol") != "BTCUSDT" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"lastUpdateId":100,"bids":[["10","1"],["9","1"]],"asks":[["11","1"],["12","1"]]}`)
+	}))
+	defer stub.Close()
+
+	s, _ := NewServer(DefaultConfig())
+	w := &WSConnection{wsConfig: WSConfig{Name: "Binance", OrderBook: OrderBookConfig{Enabled: true, URL: stub.URL}}}
+
+	// Snapshot requests stop on shutdown
+	t.Cleanup(func() {
+		w.resetDepthBooks()
+		close(s.quitCh)
+		w.depthFetches.Wait()
+	})
+	b := w.binanceDepthBook("BTCUSDT")
+
+	// Buffered until the snapshot is loaded, the first update is older than the snapshot
+	b.update(s, w, &BinanceOrderBook{Symbol: "BTCUSDT", FirstUpdateID: 90, LastUpdateID: 95, Bids: [][]string{{"8", "1"}}}, time.Now())
+	b.update(s, w, &BinanceOrderBook{Symbol: "BTCUSDT", FirstUpdateID: 96, LastUpdateID: 101, Bids: [][]string{{"9", "0"}}}, time.Now())
+
+	for i := 0; ; i++ {
+		b.Lock()
+		synced := b.synced
+		b.Unlock()
+		if synced {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("Order book is not synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	q := b.update(s, w, &BinanceOrderBook{Symbol: "BTCUSDT", FirstUpdateID: 102, LastUpdateID: 103, Asks: [][]string{{"11", "3"}}}, time.Now())
+	expectDeepEqual(t, q.Bids, [][]string{{"10", "1"}})
+	expectDeepEqual(t, q.Asks, [][]string{{"11", "3"}, {"12", "1"}})
+
+	// Gap triggers resync
+	if q := b.update(s, w, &BinanceOrderBook{Symbol: "BTCUSDT", FirstUpdateID: 105, LastUpdateID: 106}, time.Now()); q != nil {
+		t.Fatalf("Expected no quote after the gap")
+	}
+
+	b.Lock()
+	synced := b.synced
+	b.Unlock()
+	if synced {
+		t.Fatalf("Expected order book to resync")
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBinanceTrades(t *testing.T) {
+	received := make(chan Message, 2)
+	Sinks["Test"] = SinkFunc(func(s *Server, m Message) error {
+		received <- m
+		return nil
+	})
+	defer delete(Sinks, "Test")
+
+	cfg := DefaultConfig()
+	cfg.Sinks = []string{"Test"}
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.startSinks()
+	defer s.Shutdown()
+	w := &WSConnection{wsConfig: WSConfig{Name: "Binance"}}
+
+	// The buyer is the taker, "M" must not override "m"
+	trade := `{"e":"trade","E":1672515782136,"s":"BNBBTC","t":12345,"p":"0.001","q":"100","b":88,"a":50,"T":1672515782136,"m":false,"M":true}`
+	aggTrade := `{"e":"aggTrade","E":1672515782136,"s":"BNBBTC","a":12345,"p":"0.001","q":"100","f":100,"l":105,"T":1672515782136,"m":true,"M":true}`
+
+	for _, msg := range []string{trade, aggTrade} {
+		if err := WSBinanceHandler(s, w, []byte(msg)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	for _, side := range []string{TradeSideBuy, TradeSideSell} {
+		select {
+		case m := <-received:
+			trade := m.(*Trade)
+			trade.TimeRcv = 0
+			expectDeepEqual(t, trade, &Trade{
+				MessageHeader: MessageHeader{Symbol: "BNBBTC", Time: 1672515782136000, TimeSrv: 1672515782136000, Source: "Binance"},
+				TradeID:       "12345",
+				Price:         "0.001",
+				Quantity:      "100",
+				Side:          side,
+				BuyerMaker:    side == TradeSideSell,
+			})
+		case <-time.After(time.Second):
+			t.Fatalf("Trade was not delivered")
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	exmoEventInfo       = "info"
+	exmoEventSubscribed = "subscribed"
+	exmoEventUpdate     = "update"
+	exmoEventSnapshot   = "snapshot"
+	exmoEventError      = "error"
+
+	exmoTopicTrades             = "spot/trades"
+	exmoTopicTicker             = "spot/ticker"
+	exmoTopicOrderBookSnapshots = "spot/order_book_snapshots"
+	exmoTopicOrderBookUpdates   = "spot/order_book_updates"
+)
+
+type ExmoMessage struct {
+	TS      int64           `json:"ts"`
+	Event   string          `json:"event"`
+	Topic   string          `json:"topic"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type ExmoRequest struct {
+	ID     int64    `json:"id"`
+	Method string   `json:"method"`
+	Topics []string `json:"topics"`
+}
+
+// ExmoError represents the error event.
+type ExmoError struct {
+	Code    int
+	Message string
+}
+
+// Error returns the error message.
+func (e *ExmoError) Error() string {
+	return fmt.Sprintf("EXMO error %d: %s", e.Code, e.Message)
+}
+
+type ExmoTrade struct {
+	TradeID  int64  `json:"trade_id"`
+	Type     string `json:"type"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	Amount   string `json:"amount"`
+	Date     int64  `json:"date"`
+}
+
+type ExmoTicker struct {
+	BuyPrice  string `json:"buy_price"`
+	SellPrice string `json:"sell_price"`
+	LastTrade string `json:"last_trade"`
+	Updated   int64  `json:"updated"`
+}
+
+type ExmoOrderBook struct {
+	Asks [][]string `json:"ask"`
+	Bids [][]string `json:"bid"`
+}
+
+// exmoLevels strips the amount from the price levels.
+func exmoLevels(levels [][]string) ([][]string, error) {
+	r := make([][]string, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			return nil, fmt.Errorf("invalid price level %v", level)
+		}
+		r = append(r, []string{level[0], level[1]})
+	}
+	return r, nil
+}
+
+// exmoTrades converts the trades.
+func exmoTrades(symbol string, data json.RawMessage) ([]*Trade, error) {
+	trades := []ExmoTrade{}
+	if err := json.Unmarshal(data, &trades); err != nil {
+		return nil, err
+	}
+
+	r := make([]*Trade, 0, len(trades))
+	for _, t := range trades {
+		r = append(r, &Trade{
+			MessageHeader: MessageHeader{
+				Symbol: symbol,
+				Time:   t.Date * 1000000,
+			},
+
+			TradeID:    strconv.FormatInt(t.TradeID, 10),
+			Price:      t.Price,
+			Quantity:   t.Quantity,
+			Side:       t.Type,
+			BuyerMaker: t.Type == TradeSideSell,
+		})
+	}
+	return r, nil
+}
+
+// exmoQuote converts the ticker or the order book to the Quote.
+func exmoQuote(topic string, symbol string, data json.RawMessage) (*Quote, error) {
+	r := &Quote{
+		MessageHeader: MessageHeader{
+			Symbol: symbol,
+		},
+	}
+
+	switch topic {
+	case exmoTopicTicker:
+		t := &ExmoTicker{}
+		if err := json.Unmarshal(data, t); err != nil {
+			return nil, err
+		}
+
+		// Ticker provides only the best prices, quantities are unknown and set to zero
+		r.Time = t.Updated * 1000000
+		r.Asks = [][]string{{t.SellPrice, "0"}}
+		r.Bids = [][]string{{t.BuyPrice, "0"}}
+	default:
+		b := &ExmoOrderBook{}
+		if err := json.Unmarshal(data, b); err != nil {
+			return nil, err
+		}
+
+		asks, err := exmoLevels(b.Asks)
+		if err != nil {
+			return nil, err
+		}
+		bids, err := exmoLevels(b.Bids)
+		if err != nil {
+			return nil, err
+		}
+		r.Asks = asks
+		r.Bids = bids
+	}
+
+	r.AsksDepth = len(r.Asks)
+	r.BidsDepth = len(r.Bids)
+	return r, nil
+}
+
+// WSExmoHandler process message from the EXMO stream.
+func WSExmoHandler(s *Server, w *WSConnection, msg []byte) error {
+	rcv := time.Now()
+
+	message := &ExmoMessage{}
+	if err := json.Unmarshal(msg, message); err != nil {
+		return err
+	}
+
+	switch message.Event {
+	case exmoEventUpdate, exmoEventSnapshot:
+		topic, symbol, _ := strings.Cut(message.Topic, ":")
+
+		switch topic {
+		case exmoTopicTrades:
+			trades, err := exmoTrades(symbol, message.Data)
+			if err != nil {
+				return err
+			}
+
+			for _, r := range trades {
+				r.TimeSrv = message.TS * 1000
+				r.TimeRcv = rcv.UnixMicro()
+				r.Source = w.wsConfig.Name
+
+				if err := s.ProcessTrade(r); err != nil {
+					return err
+				}
+			}
+		case exmoTopicTicker, exmoTopicOrderBookSnapshots, exmoTopicOrderBookUpdates:
+			r, err := exmoQuote(topic, symbol, message.Data)
+			if err != nil {
+				return err
+			}
+			if r.Time == 0 {
+				r.Time = message.TS * 1000
+			}
+			r.TimeSrv = message.TS * 1000
+			r.TimeRcv = rcv.UnixMicro()
+			r.Source = w.wsConfig.Name
+
+			return s.ProcessQuote(r)
+		default:
+			s.Errorf("WSS %s: unknown topic '%s'", w.wsConfig.Name, message.Topic)
+		}
+	case exmoEventError:
+		return &ExmoError{Code: message.Code, Message: message.Message}
+	case exmoEventInfo:
+		s.Noticef("WSS %s: %s", w.wsConfig.Name, message.Message)
+	case exmoEventSubscribed:
+		s.Debugf("WSS %s: subscribed %s", w.wsConfig.Name, message.Topic)
+	default:
+		s.Debugf("WSS %s: unknown message %s", w.wsConfig.Name, msg)
+	}
+	return nil
+}
+
+// ExmoSubscriptionRequests builds subscribe or unsubscribe request for the topics (e.g. spot/trades:BTC_USD).
+func ExmoSubscriptionRequests(id int64, subscribe bool, topics []string) ([][]byte, error) {
+	method := "unsubscribe"
+	if subscribe {
+		method = "subscribe"
+	}
+	return jsonMessages(&ExmoRequest{ID: id, Method: method, Topics: topics})
+}
+
+func init() {
+	Handlers["EXMO"] = WSExmoHandler
+	SubscriptionBuilders["EXMO"] = ExmoSubscriptionRequests
+}
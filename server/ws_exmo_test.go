@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExmoQuote(t *testing.T) {
+	data := json.RawMessage(`{"ask":[["100","3","300"]],"bid":[["99","2","198"],["98","1","98"]]}`)
+	q, err := exmoQuote(exmoTopicOrderBookSnapshots, "BTC_USD", data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, q, &Quote{
+		MessageHeader: MessageHeader{Symbol: "BTC_USD"},
+		AsksDepth:     1,
+		Asks:          [][]string{{"100", "3"}},
+		BidsDepth:     2,
+		Bids:          [][]string{{"99", "2"}, {"98", "1"}},
+	})
+}
+
+func TestExmoTickerQuote(t *testing.T) {
+	data := json.RawMessage(`{"buy_price":"100","sell_price":"101","last_trade":"100.5","updated":1574427585}`)
+	q, err := exmoQuote(exmoTopicTicker, "BTC_USD", data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, q, &Quote{
+		MessageHeader: MessageHeader{Symbol: "BTC_USD", Time: 1574427585000000},
+		AsksDepth:     1,
+		Asks:          [][]string{{"101", "0"}},
+		BidsDepth:     1,
+		Bids:          [][]string{{"100", "0"}},
+	})
+}
+
+func TestExmoError(t *testing.T) {
+	s, _ := NewServer(DefaultConfig())
+	w := &WSConnection{wsConfig: WSConfig{Name: "EXMO"}}
+
+	err := WSExmoHandler(s, w, []byte(`{"ts":1574427585174,"event":"error","code":1,"message":"unknown topic"}`))
+	expectDeepEqual(t, err, error(&ExmoError{Code: 1, Message: "unknown topic"}))
+}
@@ -0,0 +1,417 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	krakenEventSystemStatus       = "systemStatus"
+	krakenEventSubscriptionStatus = "subscriptionStatus"
+	krakenEventHeartbeat          = "heartbeat"
+	krakenEventError              = "error"
+
+	krakenChannelOHLC   = "ohlc"
+	krakenChannelBook   = "book"
+	krakenChannelTicker = "ticker"
+	krakenChannelTrade  = "trade"
+)
+
+type KrakenEvent struct {
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Version      string `json:"version"`
+	ChannelName  string `json:"channelName"`
+	Pair         string `json:"pair"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+type KrakenSubscription struct {
+	Name     string `json:"name"`
+	Interval int    `json:"interval,omitempty"`
+	Depth    int    `json:"depth,omitempty"`
+}
+
+type KrakenRequest struct {
+	Event        string             `json:"event"`
+	ReqID        int64              `json:"reqid"`
+	Pair         []string           `json:"pair"`
+	Subscription KrakenSubscription `json:"subscription"`
+}
+
+type KrakenTicker struct {
+	Ask []string `json:"a"`
+	Bid []string `json:"b"`
+}
+
+type KrakenBook struct {
+	AsksSnapshot [][]string `json:"as"`
+	BidsSnapshot [][]string `json:"bs"`
+	Asks         [][]string `json:"a"`
+	Bids         [][]string `json:"b"`
+}
+
+// krakenIntervals maps OHLC intervals in minutes to interval names.
+var krakenIntervals = map[int]string{
+	1:     "1m",
+	5:     "5m",
+	15:    "15m",
+	30:    "30m",
+	60:    "1h",
+	240:   "4h",
+	1440:  "1d",
+	10080: "1w",
+	21600: "15d",
+}
+
+// krakenTime converts Kraken timestamp (seconds with fraction) to microseconds.
+func krakenTime(s string) (int64, error) {
+	sec, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 6 {
+		frac = frac[:6]
+	}
+	frac += strings.Repeat("0", 6-len(frac))
+
+	t, err := strconv.ParseInt(sec+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s'", s)
+	}
+	return t, nil
+}
+
+// krakenLevels strips the timestamp and flags from the price levels and returns the latest timestamp.
+func krakenLevels(levels [][]string) ([][]string, int64, error) {
+	var last int64
+	r := make([][]string, 0, len(levels))
+
+	for _, level := range levels {
+		if len(level) < 3 {
+			return nil, 0, fmt.Errorf("invalid price level %v", level)
+		}
+		t, err := krakenTime(level[2])
+		if err != nil {
+			return nil, 0, err
+		}
+		if t > last {
+			last = t
+		}
+		r = append(r, []string{level[0], level[1]})
+	}
+	return r, last, nil
+}
+
+// krakenCandle converts OHLC payload to the Candle.
+func krakenCandle(channel string, pair string, payload json.RawMessage) (*Candle, error) {
+	fields := []json.RawMessage{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	if len(fields) < 8 {
+		return nil, fmt.Errorf("invalid ohlc message %s", payload)
+	}
+
+	// The last field is the trade count, all the others are strings
+	ohlc := make([]string, 8)
+	for i := range ohlc {
+		if err := json.Unmarshal(fields[i], &ohlc[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	_, minutes, _ := strings.Cut(channel, "-")
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ohlc channel '%s'", channel)
+	}
+	interval, ok := krakenIntervals[m]
+	if !ok {
+		interval = minutes + "m"
+	}
+
+	srv, err := krakenTime(ohlc[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := krakenTime(ohlc[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Candle{
+		MessageHeader: MessageHeader{
+			Symbol:  pair,
+			Time:    end - int64(m)*int64(time.Minute/time.Microsecond),
+			TimeSrv: srv,
+		},
+
+		Interval: interval,
+		Open:     ohlc[2],
+		High:     ohlc[3],
+		Low:      ohlc[4],
+		Close:    ohlc[5],
+		Volume:   ohlc[7],
+	}, nil
+}
+
+// krakenQuote converts the ticker payload to the Quote with the best bid and ask.
+func krakenQuote(pair string, payload json.RawMessage) (*Quote, error) {
+	t := &KrakenTicker{}
+	if err := json.Unmarshal(payload, t); err != nil {
+		return nil, err
+	}
+	if len(t.Ask) < 3 || len(t.Bid) < 3 {
+		return nil, fmt.Errorf("invalid ticker message %s", payload)
+	}
+
+	return &Quote{
+		MessageHeader: MessageHeader{
+			Symbol: pair,
+		},
+
+		AsksDepth: 1,
+		Asks:      [][]string{{t.Ask[0], t.Ask[2]}},
+		BidsDepth: 1,
+		Bids:      [][]string{{t.Bid[0], t.Bid[2]}},
+	}, nil
+}
+
+// krakenTrades converts the trade payload to the list of trades.
+func krakenTrades(pair string, payload json.RawMessage) ([]*Trade, error) {
+	trades := [][]string{}
+	if err := json.Unmarshal(payload, &trades); err != nil {
+		return nil, err
+	}
+
+	r := make([]*Trade, 0, len(trades))
+	for _, t := range trades {
+		if len(t) < 4 {
+			return nil, fmt.Errorf("invalid trade %v", t)
+		}
+		tm, err := krakenTime(t[2])
+		if err != nil {
+			return nil, err
+		}
+
+		side := TradeSideBuy
+		if t[3] == "s" {
+			side = TradeSideSell
+		}
+
+		r = append(r, &Trade{
+			MessageHeader: MessageHeader{
+				Symbol:  pair,
+				Time:    tm,
+				TimeSrv: tm,
+			},
+
+			Price:      t[0],
+			Quantity:   t[1],
+			Side:       side,
+			BuyerMaker: side == TradeSideSell,
+		})
+	}
+	return r, nil
+}
+
+// krakenBookQuote converts the book snapshot or update payloads to the Quote.
+func krakenBookQuote(pair string, payloads []json.RawMessage) (*Quote, error) {
+	r := &Quote{
+		MessageHeader: MessageHeader{
+			Symbol: pair,
+		},
+
+		Asks: [][]string{},
+		Bids: [][]string{},
+	}
+
+	for _, payload := range payloads {
+		b := &KrakenBook{}
+		if err := json.Unmarshal(payload, b); err != nil {
+			return nil, err
+		}
+
+		for _, side := range []struct {
+			levels [][]string
+			dst    *[][]string
+		}{
+			{b.AsksSnapshot, &r.Asks},
+			{b.Asks, &r.Asks},
+			{b.BidsSnapshot, &r.Bids},
+			{b.Bids, &r.Bids},
+		} {
+			levels, t, err := krakenLevels(side.levels)
+			if err != nil {
+				return nil, err
+			}
+			if t > r.TimeSrv {
+				r.TimeSrv = t
+			}
+			*side.dst = append(*side.dst, levels...)
+		}
+	}
+
+	r.Time = r.TimeSrv
+	r.AsksDepth = len(r.Asks)
+	r.BidsDepth = len(r.Bids)
+	return r, nil
+}
+
+// WSKrakenHandler process message from the kraken stream.
+func WSKrakenHandler(s *Server, w *WSConnection, msg []byte) error {
+	rcv := time.Now()
+
+	// Channel messages are framed as arrays: [channelID, payload..., channelName, pair]
+	if len(msg) > 0 && msg[0] == '[' {
+		frame := []json.RawMessage{}
+		if err := json.Unmarshal(msg, &frame); err != nil {
+			return err
+		}
+		if len(frame) < 4 {
+			return fmt.Errorf("invalid kraken message %s", msg)
+		}
+
+		var channel, pair string
+		if err := json.Unmarshal(frame[len(frame)-2], &channel); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(frame[len(frame)-1], &pair); err != nil {
+			return err
+		}
+		payloads := frame[1 : len(frame)-2]
+
+		name, _, _ := strings.Cut(channel, "-")
+		switch name {
+		case krakenChannelOHLC:
+			r, err := krakenCandle(channel, pair, payloads[0])
+			if err != nil {
+				return err
+			}
+			r.TimeRcv = rcv.UnixMicro()
+			r.Source = w.wsConfig.Name
+
+			return s.ProcessCandle(r)
+		case krakenChannelTicker:
+			r, err := krakenQuote(pair, payloads[0])
+			if err != nil {
+				return err
+			}
+			r.Time = rcv.UnixMicro()
+			r.TimeSrv = rcv.UnixMicro()
+			r.TimeRcv = rcv.UnixMicro()
+			r.Source = w.wsConfig.Name
+
+			return s.ProcessQuote(r)
+		case krakenChannelTrade:
+			trades, err := krakenTrades(pair, payloads[0])
+			if err != nil {
+				return err
+			}
+
+			for _, r := range trades {
+				r.TimeRcv = rcv.UnixMicro()
+				r.Source = w.wsConfig.Name
+
+				if err := s.ProcessTrade(r); err != nil {
+					return err
+				}
+			}
+		case krakenChannelBook:
+			r, err := krakenBookQuote(pair, payloads)
+			if err != nil {
+				return err
+			}
+			r.TimeRcv = rcv.UnixMicro()
+			r.Source = w.wsConfig.Name
+
+			return s.ProcessQuote(r)
+		default:
+			s.Errorf("WSS %s: unknown channel '%s'", w.wsConfig.Name, channel)
+		}
+		return nil
+	}
+
+	message := &KrakenEvent{}
+	if err := json.Unmarshal(msg, message); err != nil {
+		return err
+	}
+
+	switch message.Event {
+	case krakenEventHeartbeat:
+	case krakenEventSystemStatus:
+		s.Noticef("WSS %s: system status '%s' version %s", w.wsConfig.Name, message.Status, message.Version)
+	case krakenEventSubscriptionStatus:
+		if message.ErrorMessage != "" {
+			s.Errorf("WSS %s: subscription %s %s: %s", w.wsConfig.Name, message.ChannelName, message.Pair, message.ErrorMessage)
+		} else {
+			s.Debugf("WSS %s: %s %s %s", w.wsConfig.Name, message.Status, message.ChannelName, message.Pair)
+		}
+	case krakenEventError:
+		s.Errorf("WSS %s: %s", w.wsConfig.Name, message.ErrorMessage)
+	default:
+		s.Debugf("WSS %s: unknown message %s", w.wsConfig.Name, msg)
+	}
+	return nil
+}
+
+// krakenSubscription parses the channel name, e.g. ohlc-5 or book-10.
+func krakenSubscription(channel string) (KrakenSubscription, error) {
+	name, param, _ := strings.Cut(channel, "-")
+	r := KrakenSubscription{Name: name}
+
+	if param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return r, fmt.Errorf("invalid channel '%s'", channel)
+		}
+
+		switch name {
+		case krakenChannelOHLC:
+			r.Interval = n
+		case krakenChannelBook:
+			r.Depth = n
+		default:
+			return r, fmt.Errorf("invalid channel '%s'", channel)
+		}
+	}
+	return r, nil
+}
+
+// KrakenSubscriptionRequests builds subscribe or unsubscribe requests for the streams (e.g. ohlc-1:XBT/USD).
+func KrakenSubscriptionRequests(id int64, subscribe bool, streams []string) ([][]byte, error) {
+	event := "unsubscribe"
+	if subscribe {
+		event = "subscribe"
+	}
+
+	// Pairs are grouped by channel
+	channels := []string{}
+	pairs := map[string][]string{}
+	for _, stream := range streams {
+		channel, pair, ok := strings.Cut(stream, ":")
+		if !ok || pair == "" {
+			return nil, fmt.Errorf("invalid stream '%s'", stream)
+		}
+		if pairs[channel] == nil {
+			channels = append(channels, channel)
+		}
+		pairs[channel] = append(pairs[channel], pair)
+	}
+
+	requests := []interface{}{}
+	for _, channel := range channels {
+		sub, err := krakenSubscription(channel)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, &KrakenRequest{Event: event, ReqID: id, Pair: pairs[channel], Subscription: sub})
+	}
+	return jsonMessages(requests...)
+}
+
+func init() {
+	Handlers["Kraken"] = WSKrakenHandler
+	SubscriptionBuilders["Kraken"] = KrakenSubscriptionRequests
+}
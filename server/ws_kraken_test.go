@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestKrakenTime(t *testing.T) {
+	expectDeepEqual(t, Unwrap(krakenTime("1542057314.748456")), int64(1542057314748456))
+	expectDeepEqual(t, Unwrap(krakenTime("1542057314.7")), int64(1542057314700000))
+	expectDeepEqual(t, Unwrap(krakenTime("1542057314")), int64(1542057314000000))
+}
+
+func TestKrakenCandle(t *testing.T) {
+	payload := json.RawMessage(`["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2]`)
+	c, err := krakenCandle("ohlc-5", "XBT/USD", payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, c, &Candle{
+		MessageHeader: MessageHeader{Symbol: "XBT/USD", Time: 1542057060435743, TimeSrv: 1542057314748456},
+		Interval:      "5m",
+		Open:          "3586.70000",
+		High:          "3586.70000",
+		Low:           "3586.60000",
+		Close:         "3586.60000",
+		Volume:        "0.03373000",
+	})
+}
+
+func TestKrakenBookQuote(t *testing.T) {
+	payloads := []json.RawMessage{
+		json.RawMessage(`{"a":[["5541.30000","2.50700000","1534614248.456738"]]}`),
+		json.RawMessage(`{"b":[["5541.20000","1.52900000","1534614248.765567","r"]],"c":"974942666"}`),
+	}
+	q, err := krakenBookQuote("XBT/USD", payloads)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, q, &Quote{
+		MessageHeader: MessageHeader{Symbol: "XBT/USD", Time: 1534614248765567, TimeSrv: 1534614248765567},
+		AsksDepth:     1,
+		Asks:          [][]string{{"5541.30000", "2.50700000"}},
+		BidsDepth:     1,
+		Bids:          [][]string{{"5541.20000", "1.52900000"}},
+	})
+}
+
+func TestKrakenTrades(t *testing.T) {
+	payload := json.RawMessage(`[["5541.20000","0.15850568","1534614057.321597","s","l",""]]`)
+	trades, err := krakenTrades("XBT/USD", payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, trades, []*Trade{{
+		MessageHeader: MessageHeader{Symbol: "XBT/USD", Time: 1534614057321597, TimeSrv: 1534614057321597},
+		Price:         "5541.20000",
+		Quantity:      "0.15850568",
+		Side:          TradeSideSell,
+		BuyerMaker:    true,
+	}})
+}
+
+func TestKrakenSubscriptionRequests(t *testing.T) {
+	r, err := KrakenSubscriptionRequests(7, true, []string{"ohlc-1:XBT/USD", "ticker:XBT/USD", "ohlc-1:ETH/USD"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectDeepEqual(t, string(r[0]), `{"event":"subscribe","reqid":7,"pair":["XBT/USD","ETH/USD"],"subscription":{"name":"ohlc","interval":1}}`)
+	expectDeepEqual(t, string(r[1]), `{"event":"subscribe","reqid":7,"pair":["XBT/USD"],"subscription":{"name":"ticker"}}`)
+
+	if _, err := KrakenSubscriptionRequests(8, false, []string{"ticker-1:XBT/USD"}); err == nil {
+		t.Fatalf("Expected error for invalid channel")
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Staleness check interval.
+const staleCheckInterval = time.Second
+
+// Per-symbol staleness threshold in seconds.
+type SymbolSilenceConfig struct {
+	Symbol     string `xml:"Symbol,attr"`
+	MaxSilence int    `xml:",chardata"`
+}
+
+// touchData records the time of the data message for the symbol.
+func (c *WSConnection) touchData(symbol string, t time.Time) {
+	c.wsLastData.Store(t.UnixMicro())
+
+	if len(c.wsConfig.SymbolSilence) > 0 {
+		c.symbolMu.Lock()
+		c.symbolLastData[symbol] = t.UnixMicro()
+		c.symbolMu.Unlock()
+	}
+}
+
+// silence returns the time without data since the last message or connect.
+func silence(connected time.Time, last int64, now time.Time) time.Duration {
+	if t := time.UnixMicro(last); last > 0 && t.After(connected) {
+		return now.Sub(t)
+	}
+	return now.Sub(connected)
+}
+
+// staleFeed returns the error if the connection or the symbol did not receive data in time.
+func (c *WSConnection) staleFeed(connected time.Time, now time.Time) (string, error) {
+	cfg := c.wsConfig
+
+	if d := silence(connected, c.wsLastData.Load(), now); cfg.MaxSilence > 0 && d > time.Duration(cfg.MaxSilence)*time.Second {
+		return "", fmt.Errorf("stale feed: no data for %v", d.Truncate(time.Second))
+	}
+
+	c.symbolMu.Lock()
+	defer c.symbolMu.Unlock()
+
+	for _, sym := range cfg.SymbolSilence {
+		if d := silence(connected, c.symbolLastData[sym.Symbol], now); sym.MaxSilence > 0 && d > time.Duration(sym.MaxSilence)*time.Second {
+			return sym.Symbol, fmt.Errorf("stale feed: no data for %s for %v", sym.Symbol, d.Truncate(time.Second))
+		}
+	}
+	return "", nil
+}
+
+// WSWatchdog recycles the websocket which does not receive data within MaxSilence.
+func (s *Server) WSWatchdog(conn *WSConnection, c *websocket.Conn) {
+	cfg := conn.wsConfig
+	if cfg.MaxSilence < 1 && len(cfg.SymbolSilence) == 0 {
+		return
+	}
+
+	connected := time.Now()
+	ticker := time.NewTicker(staleCheckInterval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.quitCh:
+				return
+			}
+
+			// Stop when the websocket is closed or replaced
+			conn.RLock()
+			current := conn.wsConn == c
+			conn.RUnlock()
+			if !current {
+				return
+			}
+
+			if symbol, err := conn.staleFeed(connected, time.Now()); err != nil {
+				s.metrics.WSStaleFeeds.Inc(cfg.Name, symbol)
+				s.WSHandleError(conn, err)
+				return
+			}
+		}
+	}()
+}
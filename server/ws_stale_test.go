@@ -0,0 +1,37 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWSStaleFeed(t *testing.T) {
+	conn := &WSConnection{
+		wsConfig: WSConfig{
+			Name:          "foo",
+			MaxSilence:    10,
+			SymbolSilence: []SymbolSilenceConfig{{Symbol: "ETHUSDT", MaxSilence: 30}},
+		},
+		symbolLastData: make(map[string]int64),
+	}
+
+	connected := time.Unix(1000, 0)
+
+	// Silence is counted from connect until the first message
+	_, err := conn.staleFeed(connected, connected.Add(5*time.Second))
+	expectDeepEqual(t, err, nil)
+	_, err = conn.staleFeed(connected, connected.Add(11*time.Second))
+	expectDeepEqual(t, err.Error(), "stale feed: no data for 11s")
+
+	// Other symbols keep the connection fresh
+	conn.touchData("BTCUSDT", connected.Add(25*time.Second))
+	_, err = conn.staleFeed(connected, connected.Add(30*time.Second))
+	expectDeepEqual(t, err, nil)
+	symbol, err := conn.staleFeed(connected, connected.Add(31*time.Second))
+	expectDeepEqual(t, symbol, "ETHUSDT")
+	expectDeepEqual(t, err.Error(), "stale feed: no data for ETHUSDT for 31s")
+
+	conn.touchData("ETHUSDT", connected.Add(31*time.Second))
+	_, err = conn.staleFeed(connected, connected.Add(35*time.Second))
+	expectDeepEqual(t, err, nil)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+// WSSubscriptionBuilder builds exchange specific subscribe or unsubscribe requests for the streams.
+type WSSubscriptionBuilder func(id int64, subscribe bool, streams []string) ([][]byte, error)
+
+var (
+	// SubscriptionBuilders contains subscription builders by handler name.
+	SubscriptionBuilders = map[string]WSSubscriptionBuilder{}
+)
+
+// Subscriptions returns the active subscriptions sorted by name.
+func (c *WSConnection) Subscriptions() []string {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	r := make([]string, 0, len(c.subscriptions))
+	for stream := range c.subscriptions {
+		r = append(r, stream)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// wsWrite sends the messages to the websocket, writes are serialized.
+func (c *WSConnection) wsWrite(ws *websocket.Conn, messages [][]byte) error {
+	c.wsWriteMu.Lock()
+	defer c.wsWriteMu.Unlock()
+
+	for _, msg := range messages {
+		if err := ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildSubscription returns requests built by the handler of the connection.
+func (c *WSConnection) buildSubscription(subscribe bool, streams []string) ([][]byte, error) {
+	builder := SubscriptionBuilders[c.wsConfig.Handler]
+	if builder == nil {
+		return nil, fmt.Errorf("handler '%s' does not support subscriptions", c.wsConfig.Handler)
+	}
+	return builder(c.requestID.Add(1), subscribe, streams)
+}
+
+// wsSubscription updates the subscription set and sends the request if connected.
+func (s *Server) wsSubscription(conn *WSConnection, subscribe bool, streams []string) error {
+	if len(streams) == 0 {
+		return fmt.Errorf("no streams")
+	}
+
+	messages, err := conn.buildSubscription(subscribe, streams)
+	if err != nil {
+		return err
+	}
+
+	conn.subMu.Lock()
+	for _, stream := range streams {
+		if subscribe {
+			conn.subscriptions[stream] = struct{}{}
+		} else {
+			delete(conn.subscriptions, stream)
+		}
+	}
+	conn.subMu.Unlock()
+
+	// Disconnected websocket receives subscriptions on connect
+	conn.RLock()
+	ws := conn.wsConn
+	conn.RUnlock()
+
+	if ws != nil {
+		if err := conn.wsWrite(ws, messages); err != nil {
+			s.WSHandleError(conn, err)
+		}
+	}
+	return nil
+}
+
+// WSSubscribe subscribes the connection to the streams.
+func (s *Server) WSSubscribe(conn *WSConnection, streams []string) error {
+	s.Noticef("WSS %s: Subscribing to %v", conn.wsConfig.Name, streams)
+	return s.wsSubscription(conn, true, streams)
+}
+
+// WSUnsubscribe unsubscribes the connection from the streams.
+func (s *Server) WSUnsubscribe(conn *WSConnection, streams []string) error {
+	s.Noticef("WSS %s: Unsubscribing from %v", conn.wsConfig.Name, streams)
+	return s.wsSubscription(conn, false, streams)
+}
+
+// wsReplaySubscriptions sends the active subscriptions after connect.
+func (s *Server) wsReplaySubscriptions(conn *WSConnection, ws *websocket.Conn) error {
+	streams := conn.Subscriptions()
+	if len(streams) == 0 {
+		return nil
+	}
+
+	messages, err := conn.buildSubscription(true, streams)
+	if err != nil {
+		return err
+	}
+
+	s.Noticef("WSS %s: Restoring subscriptions %v", conn.wsConfig.Name, streams)
+	return conn.wsWrite(ws, messages)
+}
+
+// jsonMessages marshals the requests.
+func jsonMessages(requests ...interface{}) ([][]byte, error) {
+	r := make([][]byte, 0, len(requests))
+	for _, req := range requests {
+		b, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, b)
+	}
+	return r, nil
+}
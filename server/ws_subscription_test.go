@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWSSubscriptionReplay(t *testing.T) {
+	received := make(chan string, 10)
+	conns := make(chan *websocket.Conn, 10)
+
+	upgrader := websocket.Upgrader{}
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- c
+		for {
+			_, msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}))
+	defer stub.Close()
+
+	cfg := DefaultConfig()
+	cfg.WebSocket = []WSConfig{{
+		Name:        "foo",
+		Enabled:     true,
+		URL:         "ws" + strings.TrimPrefix(stub.URL, "http"),
+		Handler:     "Binance",
+		DialTimeout: 1,
+	}}
+	srv, _ := NewServer(cfg)
+	defer close(srv.quitCh)
+
+	expect := func(msg string) {
+		select {
+		case m := <-received:
+			expectDeepEqual(t, m, msg)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected message %s", msg)
+		}
+	}
+
+	// Subscriptions are sent on connect
+	conn := srv.WSConnection("foo")
+	if err := srv.WSSubscribe(conn, []string{"btcusdt@trade"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	srv.StartWS(conn)
+	expect(`{"id":2,"method":"SUBSCRIBE","params":["btcusdt@trade"]}`)
+
+	// Live connection receives the request
+	if err := srv.WSUnsubscribe(conn, []string{"btcusdt@trade"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expect(`{"id":3,"method":"UNSUBSCRIBE","params":["btcusdt@trade"]}`)
+	srv.WSSubscribe(conn, []string{"ethusdt@trade"})
+	expect(`{"id":4,"method":"SUBSCRIBE","params":["ethusdt@trade"]}`)
+
+	// Subscriptions are restored after reconnect
+	(<-conns).Close()
+	expect(`{"id":5,"method":"SUBSCRIBE","params":["ethusdt@trade"]}`)
+	expectDeepEqual(t, conn.Subscriptions(), []string{"ethusdt@trade"})
+}